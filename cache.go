@@ -0,0 +1,113 @@
+package gotube
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/tnychn/gotube/cache"
+	"github.com/tnychn/gotube/data"
+	"github.com/tnychn/gotube/utils"
+)
+
+var responseCache cache.Cache
+
+// SetCache sets the cache used to store the player responses of initialized videos and the players ('base.js').
+// A cached player response is kept until its stream urls expire. A nil `c` disables caching.
+// The watch and embed pages are not cached as such, only what is used from them
+// (the url of the player, the age restriction and the initial data) is stored along with the player response.
+// A cached player response or player failing to be descrambled or decrypted is dropped and fetched again.
+func SetCache(c cache.Cache) {
+	responseCache = c
+}
+
+// playerCacheTTL is how long a player is cached, since the player of an url never changes.
+const playerCacheTTL = time.Hour * 24
+
+type cachedVideo struct {
+	InfoRaw         string    `json:"info_raw"`
+	JSURL           string    `json:"js_url"`
+	IsAgeRestricted bool      `json:"is_age_restricted"`
+	FetchedAt       time.Time `json:"fetched_at"`
+
+	InitialData *data.InitialData `json:"initial_data,omitempty"`
+}
+
+func (video *Video) cacheKey() string {
+	return "video:" + video.ID
+}
+
+func (video *Video) loadCache() bool {
+	if responseCache == nil {
+		return false
+	}
+	content, ok := responseCache.Get(video.cacheKey())
+	if !ok {
+		return false
+	}
+	var cached cachedVideo
+	if err := json.Unmarshal(content, &cached); err != nil {
+		return false
+	}
+	video.infoRaw = cached.InfoRaw
+	video.jsURL = cached.JSURL
+	video.IsAgeRestricted = cached.IsAgeRestricted
+	video.fetchedAt = cached.FetchedAt
+	video.initialData = cached.InitialData
+	return true
+}
+
+// dropCache removes the cached player response of this video (and the player it refers to), if any.
+func (video *Video) dropCache() {
+	if responseCache == nil {
+		return
+	}
+	_ = responseCache.Delete(video.cacheKey())
+	if video.jsURL != "" {
+		_ = responseCache.Delete(playerCacheKey(video.jsURL))
+	}
+	video.cached = false
+}
+
+func (video *Video) storeCache() {
+	if responseCache == nil {
+		return
+	}
+	ttl := time.Until(video.expiration())
+	if ttl <= 0 {
+		return
+	}
+	content, err := json.Marshal(cachedVideo{
+		InfoRaw:         video.infoRaw,
+		JSURL:           video.jsURL,
+		IsAgeRestricted: video.IsAgeRestricted,
+		FetchedAt:       video.fetchedAt,
+		InitialData:     video.initialData,
+	})
+	if err != nil {
+		return
+	}
+	_ = responseCache.Set(video.cacheKey(), content, ttl)
+}
+
+func playerCacheKey(jsURL string) string {
+	return "player:" + jsURL
+}
+
+// fetchPlayer fetches the content of the player ('base.js') at `jsURL`, from the cache if possible.
+// `cached` reports whether the content comes from the cache.
+func fetchPlayer(jsURL string) (js string, cached bool, err error) {
+	key := playerCacheKey(jsURL)
+	if responseCache != nil {
+		if content, ok := responseCache.Get(key); ok {
+			return string(content), true, nil
+		}
+	}
+	content, err := utils.HttpFetch(jsURL)
+	if err != nil {
+		return "", false, err
+	}
+	if responseCache != nil {
+		_ = responseCache.Set(key, content, playerCacheTTL)
+	}
+	return string(content), false, nil
+}
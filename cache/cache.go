@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Cache stores values by key for a limited amount of time.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value of `key`, and false if it does not exist or has expired.
+	Get(key string) ([]byte, bool)
+	// Set stores `value` under `key` for the duration of `ttl`.
+	Set(key string, value []byte, ttl time.Duration) error
+	// Delete removes `key`.
+	Delete(key string) error
+}
+
+type entry struct {
+	value   []byte
+	expires time.Time
+}
+
+// Memory is an in-memory cache.
+type Memory struct {
+	lock    sync.Mutex
+	entries map[string]entry
+}
+
+// NewMemory returns a new empty in-memory cache.
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]entry)}
+}
+
+func (m *Memory) Get(key string) ([]byte, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(e.expires) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (m *Memory) Set(key string, value []byte, ttl time.Duration) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.entries[key] = entry{value: value, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+func (m *Memory) Delete(key string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+// Dir is an on-disk cache which stores each value in a file under a directory.
+// The first line of each file holds the expiration time (in unix nanoseconds) of the value.
+type Dir struct {
+	path string
+}
+
+// NewDir returns a new on-disk cache in the directory of `path`, creating it if necessary.
+func NewDir(path string) (*Dir, error) {
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &Dir{path: path}, nil
+}
+
+func (d *Dir) filename(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(d.path, hex.EncodeToString(sum[:]))
+}
+
+func (d *Dir) Get(key string) ([]byte, bool) {
+	content, err := ioutil.ReadFile(d.filename(key))
+	if err != nil {
+		return nil, false
+	}
+	i := bytes.IndexByte(content, '\n')
+	if i < 0 {
+		return nil, false
+	}
+	expires, err := strconv.ParseInt(string(content[:i]), 10, 64)
+	if err != nil || time.Now().After(time.Unix(0, expires)) {
+		_ = d.Delete(key)
+		return nil, false
+	}
+	return content[i+1:], true
+}
+
+func (d *Dir) Set(key string, value []byte, ttl time.Duration) error {
+	expires := strconv.FormatInt(time.Now().Add(ttl).UnixNano(), 10)
+	content := append([]byte(expires+"\n"), value...)
+	// write to a temporary file first so that readers never see a partially written value
+	path := d.filename(key)
+	if err := ioutil.WriteFile(path+".part", content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".part", path)
+}
+
+func (d *Dir) Delete(key string) error {
+	if err := os.Remove(d.filename(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
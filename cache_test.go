@@ -0,0 +1,55 @@
+package gotube
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tnychn/gotube/cache"
+)
+
+func TestCorruptedCachedPlayer(t *testing.T) {
+	js, err := ioutil.ReadFile("fixture/testdata/synthetic-reverse-swap/base.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		_, _ = w.Write(js)
+	}))
+	defer server.Close()
+
+	c := cache.NewMemory()
+	SetCache(c)
+	defer SetCache(nil)
+	video := &Video{ID: "dQw4w9WgXcQ", jsURL: server.URL + "/s/player/synth001/base.js"}
+	_ = c.Set(playerCacheKey(video.jsURL), []byte("var truncated={"), playerCacheTTL)
+
+	if err = video.loadDecryption(); err != nil {
+		t.Fatal(err)
+	}
+	if fetches != 1 {
+		t.Errorf("fetched the player %d times, want 1", fetches)
+	}
+	if content, ok := c.Get(playerCacheKey(video.jsURL)); !ok || string(content) != string(js) {
+		t.Error("the corrupted player was not replaced in the cache")
+	}
+	signature, err := video.decryption.DecryptSignature("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "iIHGFEDCBAzyJwvutsrqponmlkjxhgfedcba9876540213"; signature != want {
+		t.Errorf("got %q, want %q", signature, want)
+	}
+
+	// a player which is not cached is not fetched again
+	video = &Video{ID: "dQw4w9WgXcQ", js: "var broken={", jsURL: video.jsURL}
+	if err = video.loadDecryption(); err == nil {
+		t.Error("expected an error")
+	}
+	if fetches != 1 {
+		t.Errorf("fetched the player %d times, want 1", fetches)
+	}
+}
@@ -1,15 +1,18 @@
 package gotube
 
 import (
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
-	"html"
-	"math"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	captionfmt "github.com/tnychn/gotube/caption"
 	"github.com/tnychn/gotube/data"
+	"github.com/tnychn/gotube/errors"
 	"github.com/tnychn/gotube/utils"
 )
 
@@ -17,69 +20,323 @@ import (
 type Captions []*Caption
 
 // LanguageCode returns the `Caption` of the given language code.
-func (captions Captions) LanguageCode(lc string) *Caption {
+// A manual caption is preferred over an auto-generated one of the same language.
+func (captions Captions) LanguageCode(lc string) (result *Caption) {
 	for _, caption := range captions {
-		if caption.LanguageCode == lc {
+		if caption.LanguageCode != lc {
+			continue
+		}
+		if !caption.IsAutoGenerated {
 			return caption
 		}
+		if result == nil {
+			result = caption
+		}
+	}
+	return
+}
+
+// Manual returns a copy of `Captions` containing only the captions that are not auto-generated.
+func (captions Captions) Manual() (results Captions) {
+	for _, caption := range captions {
+		if !caption.IsAutoGenerated {
+			results = append(results, caption)
+		}
+	}
+	return
+}
+
+// AutoGenerated returns a copy of `Captions` containing only the auto-generated captions (automatic speech recognition).
+func (captions Captions) AutoGenerated() (results Captions) {
+	for _, caption := range captions {
+		if caption.IsAutoGenerated {
+			results = append(results, caption)
+		}
+	}
+	return
+}
+
+// AudioTrack returns a copy of `Captions` containing only the captions available for the audio track of the given id.
+func (captions Captions) AudioTrack(id string) (results Captions) {
+	for _, caption := range captions {
+		for _, trackID := range caption.AudioTrackIDs {
+			if trackID == id {
+				results = append(results, caption)
+				break
+			}
+		}
+	}
+	return
+}
+
+// Translatable returns a copy of `Captions` containing only the captions that can be translated.
+func (captions Captions) Translatable() (results Captions) {
+	for _, caption := range captions {
+		if caption.IsTranslatable {
+			results = append(results, caption)
+		}
 	}
+	return
+}
+
+// TranslationLanguage is a language that captions can be translated into.
+type TranslationLanguage struct {
+	LanguageCode string `json:"language_code"`
+	Name         string `json:"name"`
+}
+
+// CaptionMatch is a cue of a caption matching a search query.
+type CaptionMatch struct {
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+	Text  string        `json:"text"`
+	// URL is the url of the video starting at the cue.
+	URL string `json:"url,omitempty"`
+}
+
+type captionMatchJSON CaptionMatch
+
+// MarshalJSON marshals this match with its start and end in seconds.
+func (match CaptionMatch) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		*captionMatchJSON
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+	}{(*captionMatchJSON)(&match), seconds(match.Start), seconds(match.End)})
+}
+
+// UnmarshalJSON unmarshals this match with its start and end in seconds (see `MarshalJSON()`).
+func (match *CaptionMatch) UnmarshalJSON(b []byte) error {
+	v := struct {
+		*captionMatchJSON
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+	}{captionMatchJSON: (*captionMatchJSON)(match)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	match.Start, match.End = fromSeconds(v.Start), fromSeconds(v.End)
 	return nil
 }
 
 // Caption represents a caption of a YouTube video.
 type Caption struct {
-	content string
+	contents     map[string]string
+	watchURL     string
+	translations []TranslationLanguage
+
+	URL          string `json:"url"`
+	Name         string `json:"name"`
+	LanguageCode string `json:"language_code"`
+	// Kind is the kind of this caption as reported by YouTube ('asr' for auto-generated, otherwise empty).
+	Kind            string `json:"kind,omitempty"`
+	IsAutoGenerated bool   `json:"is_auto_generated"`
+	// AudioTrackIDs lists the ids of the audio tracks that this caption is available for (if the video has multiple).
+	AudioTrackIDs  []string `json:"audio_track_ids,omitempty"`
+	IsTranslatable bool     `json:"is_translatable"`
+	// TranslatedFrom is the language code of the original caption if this caption is a translation.
+	TranslatedFrom string `json:"translated_from,omitempty"`
+}
+
+// TranslationLanguages returns the languages that this caption can be translated into.
+func (caption *Caption) TranslationLanguages() []TranslationLanguage {
+	if !caption.IsTranslatable {
+		return nil
+	}
+	return caption.translations
+}
+
+// Translate returns a new caption which is the translation of this caption into the language of the given code.
+func (caption *Caption) Translate(lc string) (*Caption, error) {
+	if !caption.IsTranslatable {
+		return nil, fmt.Errorf("caption '%v' is not translatable", caption.Name)
+	}
+	for _, language := range caption.translations {
+		if language.LanguageCode != lc {
+			continue
+		}
+		u, err := url.Parse(caption.URL)
+		if err != nil {
+			return nil, err
+		}
+		query := u.Query()
+		query.Set("tlang", lc)
+		u.RawQuery = query.Encode()
+		from := caption.LanguageCode
+		if caption.TranslatedFrom != "" {
+			// translating a translation replaces its target language, the original stays the same
+			from = caption.TranslatedFrom
+		}
+		return &Caption{
+			watchURL:        caption.watchURL,
+			translations:    caption.translations,
+			URL:             u.String(),
+			Name:            fmt.Sprintf("%v (from %v)", language.Name, caption.Name),
+			LanguageCode:    lc,
+			Kind:            caption.Kind,
+			IsAutoGenerated: caption.IsAutoGenerated,
+			AudioTrackIDs:   caption.AudioTrackIDs,
+			IsTranslatable:  true,
+			TranslatedFrom:  from,
+		}, nil
+	}
+	return nil, fmt.Errorf("caption '%v' cannot be translated into '%v'", caption.Name, lc)
+}
 
-	URL          string
-	Name         string
-	LanguageCode string
+// Search retrieves the cues of this caption by calling `Cues()` and returns the ones containing `query`,
+// ignoring case and differences in whitespace.
+func (caption *Caption) Search(query string) ([]CaptionMatch, error) {
+	query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+	return caption.SearchFunc(func(text string) bool {
+		return strings.Contains(strings.ToLower(text), query)
+	})
+}
+
+// SearchFunc retrieves the cues of this caption by calling `Cues()` and returns the ones satisfying `match`.
+// The text passed to `match` has its lines joined by spaces.
+func (caption *Caption) SearchFunc(match func(text string) bool) ([]CaptionMatch, error) {
+	cues, err := caption.Cues()
+	if err != nil {
+		return nil, err
+	}
+	var matches []CaptionMatch
+	for _, cue := range cues {
+		text := strings.Join(strings.Fields(cue.Text), " ")
+		if !match(text) {
+			continue
+		}
+		m := CaptionMatch{Start: cue.Start, End: cue.End, Text: text}
+		if caption.watchURL != "" {
+			m.URL = fmt.Sprintf("%v&t=%ds", caption.watchURL, int(cue.Start.Seconds()))
+		}
+		matches = append(matches, m)
+	}
+	return matches, nil
 }
 
 // GetContent retrieves the content of this caption (most likely in xml format).
 func (caption *Caption) GetContent() (string, error) {
-	if caption.content == "" {
-		content, err := utils.HttpFetch(caption.URL)
+	return caption.GetContentFormat("")
+}
+
+// GetContentFormat retrieves the content of this caption in the given format ('srv3' or 'json3'),
+// which carries the styling, positions and word-level timing that the default format lacks.
+// If `format` is empty, it retrieves the content in the default format (see `GetContent()`).
+func (caption *Caption) GetContentFormat(format string) (string, error) {
+	if content, ok := caption.contents[format]; ok {
+		return content, nil
+	}
+	u := caption.URL
+	if format != "" {
+		parsed, err := url.Parse(caption.URL)
 		if err != nil {
 			return "", err
 		}
-		caption.content = string(content)
+		query := parsed.Query()
+		query.Set("fmt", format)
+		parsed.RawQuery = query.Encode()
+		u = parsed.String()
+	}
+	content, err := utils.HttpFetch(u)
+	if err != nil {
+		return "", errors.OpError{Op: "fetch caption", URL: u, Err: err}
+	}
+	if caption.contents == nil {
+		caption.contents = make(map[string]string)
 	}
-	return caption.content, nil
+	caption.contents[format] = string(content)
+	return caption.contents[format], nil
 }
 
-// GetWebVTT first retrieves the content of this caption by calling `GetContent()` then converts and returns it in WebVTT format.
-func (caption *Caption) GetWebVTT() (string, error) {
-	timestr := func(f float64) string {
-		i, frac := math.Modf(f)
-		mins := int(i / 60)
-		secs := int(math.Mod(i, 60))
-		ms := fmt.Sprintf("%.3f", frac)
-		return fmt.Sprintf("%02d:%d.%v", mins, secs, strings.ReplaceAll(ms, "0.", ""))
+// StyledCues first retrieves the content of this caption in the given format ('srv3' or 'json3', defaults to 'json3')
+// by calling `GetContentFormat()` then parses it into cues with segments (styling and word-level timing) and positions.
+func (caption *Caption) StyledCues(format string) ([]captionfmt.Cue, error) {
+	if format == "" {
+		format = "json3"
+	}
+	var parse func([]byte) ([]captionfmt.Cue, error)
+	switch format {
+	case "srv3":
+		parse = captionfmt.ParseSRV3
+	case "json3":
+		parse = captionfmt.ParseJSON3
+	default:
+		return nil, fmt.Errorf("unsupported styled caption format '%v'", format)
 	}
+	content, err := caption.GetContentFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	return parse([]byte(content))
+}
+
+// Cues first retrieves the content of this caption by calling `GetContent()` then parses it into cues.
+func (caption *Caption) Cues() ([]captionfmt.Cue, error) {
 	content, err := caption.GetContent()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	// do conversion (xml to webvtt)
 	var transcript data.Transcript
 	if err = xml.Unmarshal([]byte(content), &transcript); err != nil {
+		return nil, err
+	}
+	return captionfmt.FromTranscript(&transcript), nil
+}
+
+// Convert first retrieves the cues of this caption by calling `Cues()` then converts and returns them in the given format.
+// See `caption.Names()` for the available formats.
+func (caption *Caption) Convert(format string) (string, error) {
+	return caption.convert(format, false)
+}
+
+// ConvertStyled is like `Convert()`, but it retrieves the cues by calling `StyledCues()`,
+// so that the styling, positions and word-level timing are kept by the formats supporting them (i.e. 'vtt' and 'ass').
+func (caption *Caption) ConvertStyled(format string) (string, error) {
+	return caption.convert(format, true)
+}
+
+func (caption *Caption) convert(format string, styled bool) (string, error) {
+	f, err := captionfmt.Lookup(format)
+	if err != nil {
+		return "", err
+	}
+	var cues []captionfmt.Cue
+	if styled {
+		cues, err = caption.StyledCues("")
+	} else {
+		cues, err = caption.Cues()
+	}
+	if err != nil {
 		return "", err
 	}
-	lines := []string{"WEBVTT\n"}
-	for _, text := range transcript.Texts {
-		start := text.Start
-		end := text.Start + text.Duration
-		line := fmt.Sprintf("%v --> %v\n%v\n", timestr(start), timestr(end), html.UnescapeString(text.Text))
-		lines = append(lines, line)
+	var b strings.Builder
+	if err = f.Write(&b, cues); err != nil {
+		return "", err
 	}
-	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+	return b.String(), nil
+}
+
+// GetWebVTT first retrieves the content of this caption by calling `GetContent()` then converts and returns it in WebVTT format.
+func (caption *Caption) GetWebVTT() (string, error) {
+	return caption.Convert("vtt")
 }
 
 // Save downloads the content of this caption and saves it to a file in the local machine.
 // If `destdir` is empty, it defaults to the current directory.
-// If `filename` is empty, it defaults to the name of this caption.
-// If `webvtt` is true, it saves the content in a WebVTT (.vtt) format file.
-func (caption *Caption) Save(destdir, filename string, webvtt bool) (finalpath string, err error) {
+// If `filename` is empty, it defaults to the name of this caption (with the characters not allowed in filenames replaced),
+// or to '<language code>.from.<original language code>' if this caption is a translation.
+// If `format` is empty or "xml", it saves the original content in a .xml file,
+// otherwise it converts the content into the given format (see `Convert()`).
+func (caption *Caption) Save(destdir, filename, format string) (string, error) {
+	return caption.save(destdir, filename, format, false)
+}
+
+// SaveStyled is like `Save()`, but it converts the content with `ConvertStyled()`.
+func (caption *Caption) SaveStyled(destdir, filename, format string) (string, error) {
+	return caption.save(destdir, filename, format, true)
+}
+
+func (caption *Caption) save(destdir, filename, format string, styled bool) (finalpath string, err error) {
 	if destdir == "" {
 		if destdir, err = os.Getwd(); err != nil {
 			return
@@ -89,14 +346,24 @@ func (caption *Caption) Save(destdir, filename string, webvtt bool) (finalpath s
 		return
 	}
 	if filename == "" {
-		filename = caption.Name
+		filename = utils.SanitizeFilename(caption.Name)
+		if caption.TranslatedFrom != "" {
+			// the name of a translation contains parentheses and the names of two languages
+			filename = caption.LanguageCode + ".from." + caption.TranslatedFrom
+		}
 	}
 
 	var content string
-	if webvtt {
-		content, err = caption.GetWebVTT()
-	} else {
+	ext := "xml"
+	if format == "" || format == "xml" {
 		content, err = caption.GetContent()
+	} else {
+		var f *captionfmt.Format
+		if f, err = captionfmt.Lookup(format); err != nil {
+			return
+		}
+		ext = f.Extension
+		content, err = caption.convert(format, styled)
 	}
 	if err != nil {
 		return
@@ -113,11 +380,7 @@ func (caption *Caption) Save(destdir, filename string, webvtt bool) (finalpath s
 		return
 	}
 
-	ext := ".xml"
-	if webvtt {
-		ext = ".vtt"
-	}
-	finalpath = filepath.Join(destdir, filename+ext)
+	finalpath = filepath.Join(destdir, filename+"."+ext)
 	err = os.Rename(path, finalpath)
 	return
 }
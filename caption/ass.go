@@ -0,0 +1,131 @@
+package caption
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	assWidth  = 384
+	assHeight = 288
+)
+
+const assHeader = `[Script Info]
+ScriptType: v4.00+
+PlayResX: %d
+PlayResY: %d
+WrapStyle: 0
+ScaledBorderAndShadow: yes
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Default,Arial,16,&H00FFFFFF,&H000000FF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,1,0,2,10,10,10,1
+Style: Box,Arial,16,&H00FFFFFF,&H000000FF,&H40000000,&H40000000,0,0,0,0,100,100,0,0,3,1,0,2,10,10,10,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+`
+
+var assEscaper = strings.NewReplacer("\\", "\\\\", "{", "\\{", "}", "\\}", "\n", "\\N")
+
+// assTime formats `d` as 'H:MM:SS.cc'.
+func assTime(d time.Duration) string {
+	cs := d.Milliseconds() / 10
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
+
+// assColor converts a '#RRGGBB' color into '&HBBGGRR&'.
+func assColor(color string) string {
+	c := strings.TrimPrefix(color, "#")
+	if len(c) != 6 {
+		return ""
+	}
+	return "&H" + c[4:6] + c[2:4] + c[0:2] + "&"
+}
+
+// boxed reports whether any segment of the cue has a background color,
+// in which case the cue is written in the 'Box' style drawing an opaque box behind the text (BorderStyle=3).
+func (cue Cue) boxed() bool {
+	for _, segment := range cue.Segments {
+		if segment.Style.BackgroundColor != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// assText returns the text of the cue with its position, styling and word-level timing (as karaoke) as override tags.
+// The background colors are set as the outline color, which is the color of the box in the 'Box' style.
+func assText(cue Cue) string {
+	var b strings.Builder
+	if p := cue.Position; p != nil {
+		// the numpad-like alignment of ASS counts the rows from the bottom
+		fmt.Fprintf(&b, "{\\an%d\\pos(%d,%d)}", (2-p.Anchor/3%3)*3+p.Anchor%3+1, p.Horizontal*assWidth/100, p.Vertical*assHeight/100)
+	}
+	if !cue.styled() {
+		b.WriteString(assEscaper.Replace(cue.Text))
+		return b.String()
+	}
+	karaoke := false
+	for _, segment := range cue.Segments {
+		if segment.Offset != 0 {
+			karaoke = true
+		}
+	}
+	for i, segment := range cue.Segments {
+		var tags string
+		if karaoke {
+			end := cue.End - cue.Start
+			if i+1 < len(cue.Segments) {
+				end = cue.Segments[i+1].Offset
+			}
+			if d := (end - segment.Offset).Milliseconds() / 10; d > 0 {
+				tags += fmt.Sprintf("\\k%d", d)
+			}
+		}
+		style := segment.Style
+		tags += fmt.Sprintf("\\b%d\\i%d\\u%d", b2i(style.Bold), b2i(style.Italic), b2i(style.Underline))
+		if c := assColor(style.Color); c != "" {
+			tags += "\\c" + c
+		} else {
+			tags += "\\c"
+		}
+		if cue.boxed() {
+			if c := assColor(style.BackgroundColor); c != "" {
+				tags += "\\3c" + c
+			} else {
+				tags += "\\3c"
+			}
+		}
+		b.WriteString("{" + tags + "}" + assEscaper.Replace(segment.Text))
+	}
+	return b.String()
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// WriteASS writes the cues in Advanced SubStation Alpha (ASS) format.
+// The positions, the styling of the segments (including their background colors)
+// and the word-level timing (as karaoke) are written as override tags.
+func WriteASS(w io.Writer, cues []Cue) error {
+	if _, err := fmt.Fprintf(w, assHeader, assWidth, assHeight); err != nil {
+		return err
+	}
+	for _, cue := range cues {
+		style := "Default"
+		if cue.boxed() {
+			style = "Box"
+		}
+		if _, err := fmt.Fprintf(w, "Dialogue: 0,%v,%v,%v,,0,0,0,,%v\n", assTime(cue.Start), assTime(cue.End), style, assText(cue)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
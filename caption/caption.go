@@ -0,0 +1,87 @@
+package caption
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tnychn/gotube/data"
+)
+
+// Cue is a piece of text displayed within a period of time.
+// Cues parsed from the styled formats (see `ParseSRV3()` and `ParseJSON3()`) also carry their segments and position,
+// while `Text` is always the plain text of the whole cue.
+type Cue struct {
+	Start    time.Duration
+	End      time.Duration
+	Text     string
+	Segments []Segment
+	Position *Position
+}
+
+// FromTranscript converts the texts of `transcript` into cues, unescaping the html entities within them.
+func FromTranscript(transcript *data.Transcript) []Cue {
+	cues := make([]Cue, 0, len(transcript.Texts))
+	for _, text := range transcript.Texts {
+		start := seconds(text.Start)
+		cues = append(cues, Cue{
+			Start: start,
+			End:   start + seconds(text.Duration),
+			Text:  html.UnescapeString(text.Text),
+		})
+	}
+	return cues
+}
+
+func seconds(f float64) time.Duration {
+	return time.Duration(f*1000+0.5) * time.Millisecond
+}
+
+// Writer writes `cues` to `w` in a specific format.
+type Writer func(w io.Writer, cues []Cue) error
+
+// Format is a caption format which cues can be written in.
+type Format struct {
+	Name      string
+	Extension string
+	Write     Writer
+}
+
+var formats = make(map[string]*Format)
+
+// Register registers the format of the given name, which is saved with the file extension `ext` (without the dot).
+// It replaces any format previously registered with the same name.
+func Register(name, ext string, writer Writer) {
+	formats[name] = &Format{Name: name, Extension: ext, Write: writer}
+}
+
+// Lookup returns the format registered with the given name.
+func Lookup(name string) (*Format, error) {
+	format, ok := formats[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown caption format '%v' (available: %v)", name, strings.Join(Names(), ", "))
+	}
+	return format, nil
+}
+
+// Names returns the names of all the registered formats, sorted.
+func Names() (names []string) {
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+func init() {
+	Register("vtt", "vtt", WriteWebVTT)
+	Register("srt", "srt", WriteSRT)
+	Register("ttml", "ttml", WriteTTML)
+	Register("json", "json", WriteJSON)
+	Register("lrc", "lrc", WriteLRC)
+	Register("txt", "txt", WriteText)
+	Register("ass", "ass", WriteASS)
+}
@@ -0,0 +1,100 @@
+package caption
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// clock formats `d` as hours, minutes, seconds and milliseconds separated by `sep` before the milliseconds.
+func clock(d time.Duration, sep string) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%v%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// oneLine joins the lines of `text` with spaces.
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// WriteSRT writes the cues in SubRip (SRT) format.
+func WriteSRT(w io.Writer, cues []Cue) error {
+	for i, cue := range cues {
+		if _, err := fmt.Fprintf(w, "%d\n%v --> %v\n%v\n\n", i+1, clock(cue.Start, ","), clock(cue.End, ","), cue.Text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type ttmlParagraph struct {
+	Begin string `xml:"begin,attr"`
+	End   string `xml:"end,attr"`
+	Text  string `xml:",chardata"`
+}
+
+type ttmlDocument struct {
+	XMLName    xml.Name        `xml:"tt"`
+	Namespace  string          `xml:"xmlns,attr"`
+	Paragraphs []ttmlParagraph `xml:"body>div>p"`
+}
+
+// WriteTTML writes the cues in Timed Text Markup Language (TTML) format.
+func WriteTTML(w io.Writer, cues []Cue) error {
+	document := ttmlDocument{Namespace: "http://www.w3.org/ns/ttml"}
+	for _, cue := range cues {
+		document.Paragraphs = append(document.Paragraphs, ttmlParagraph{
+			Begin: clock(cue.Start, "."),
+			End:   clock(cue.End, "."),
+			Text:  cue.Text,
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(document)
+}
+
+type jsonCue struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// WriteJSON writes the cues as a JSON array of objects with start and end (in seconds) and text.
+func WriteJSON(w io.Writer, cues []Cue) error {
+	list := make([]jsonCue, 0, len(cues))
+	for _, cue := range cues {
+		list = append(list, jsonCue{Start: cue.Start.Seconds(), End: cue.End.Seconds(), Text: cue.Text})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(list)
+}
+
+// WriteLRC writes the cues in LRC (synchronized lyrics) format.
+func WriteLRC(w io.Writer, cues []Cue) error {
+	for _, cue := range cues {
+		cs := cue.Start.Milliseconds() / 10
+		if _, err := fmt.Fprintf(w, "[%02d:%02d.%02d]%v\n", cs/6000, cs/100%60, cs%100, oneLine(cue.Text)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteText writes the text of the cues without any timing, one cue per line.
+func WriteText(w io.Writer, cues []Cue) error {
+	for _, cue := range cues {
+		if _, err := fmt.Fprintln(w, oneLine(cue.Text)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
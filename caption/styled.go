@@ -0,0 +1,193 @@
+package caption
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tnychn/gotube/data"
+)
+
+// Style is the styling of a segment.
+// The colors are in '#RRGGBB' format, or empty for the default colors.
+type Style struct {
+	Bold            bool
+	Italic          bool
+	Underline       bool
+	Color           string
+	BackgroundColor string
+}
+
+// Segment is a part of the text of a cue, which appears `Offset` after the start of the cue (i.e. word-level timing).
+type Segment struct {
+	Offset time.Duration
+	Text   string
+	Style  Style
+}
+
+// Position is the position of a cue on the screen.
+// `Anchor` is the point of the cue placed at the position, numbered from 0 (top left) to 8 (bottom right) row by row,
+// `Horizontal` and `Vertical` are percentages of the width and the height of the screen.
+type Position struct {
+	Anchor     int
+	Horizontal int
+	Vertical   int
+}
+
+// DefaultPosition is the position of the cues without one (centered at the bottom of the screen).
+var DefaultPosition = Position{Anchor: 7, Horizontal: 50, Vertical: 100}
+
+func position(anchor, horizontal, vertical *int) *Position {
+	p := DefaultPosition
+	if anchor != nil {
+		p.Anchor = *anchor
+	}
+	if horizontal != nil {
+		p.Horizontal = *horizontal
+	}
+	if vertical != nil {
+		p.Vertical = *vertical
+	}
+	if p == DefaultPosition {
+		return nil
+	}
+	return &p
+}
+
+func color(c *int) string {
+	if c == nil {
+		return ""
+	}
+	return fmt.Sprintf("#%06X", *c&0xFFFFFF)
+}
+
+// styled reports whether the cue has any segment with styling or word-level timing.
+func (cue Cue) styled() bool {
+	for _, segment := range cue.Segments {
+		if segment.Offset != 0 || segment.Style != (Style{}) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSRV3 parses a caption in 'srv3' format (fetched with 'fmt=srv3') into cues with segments and positions.
+func ParseSRV3(content []byte) ([]Cue, error) {
+	var timedtext data.TimedText
+	if err := xml.Unmarshal(content, &timedtext); err != nil {
+		return nil, err
+	}
+	return FromTimedText(&timedtext), nil
+}
+
+// FromTimedText converts the paragraphs of a caption in 'srv3' format into cues.
+func FromTimedText(timedtext *data.TimedText) []Cue {
+	pens := make(map[string]Style)
+	for _, pen := range timedtext.Pens {
+		pens[pen.ID] = Style{
+			Bold:            pen.Bold == "1",
+			Italic:          pen.Italic == "1",
+			Underline:       pen.Underline == "1",
+			Color:           strings.ToUpper(pen.ForeColor),
+			BackgroundColor: strings.ToUpper(pen.BackgroundColor),
+		}
+	}
+	positions := make(map[string]*Position)
+	for _, wp := range timedtext.Positions {
+		positions[wp.ID] = position(wp.Anchor, wp.Horizontal, wp.Vertical)
+	}
+	var cues []Cue
+	for _, p := range timedtext.Paragraphs {
+		cue := Cue{
+			Start:    time.Duration(p.Start) * time.Millisecond,
+			End:      time.Duration(p.Start+p.Duration) * time.Millisecond,
+			Position: positions[p.Position],
+		}
+		if len(p.Segments) == 0 {
+			cue.Segments = []Segment{{Text: p.Text, Style: pens[p.Pen]}}
+		}
+		for _, s := range p.Segments {
+			pen := s.Pen
+			if pen == "" {
+				pen = p.Pen
+			}
+			cue.Segments = append(cue.Segments, Segment{
+				Offset: time.Duration(s.Offset) * time.Millisecond,
+				Text:   s.Text,
+				Style:  pens[pen],
+			})
+		}
+		if cue = cue.withText(); cue.Text != "" {
+			cues = append(cues, cue)
+		}
+	}
+	return cues
+}
+
+// ParseJSON3 parses a caption in 'json3' format (fetched with 'fmt=json3') into cues with segments and positions.
+func ParseJSON3(content []byte) ([]Cue, error) {
+	var document data.JSON3
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	return FromJSON3(&document), nil
+}
+
+// FromJSON3 converts the events of a caption in 'json3' format into cues.
+func FromJSON3(document *data.JSON3) []Cue {
+	pen := func(id *int) Style {
+		if id == nil || *id < 0 || *id >= len(document.Pens) {
+			return Style{}
+		}
+		p := document.Pens[*id]
+		return Style{
+			Bold:            p.BAttr == 1,
+			Italic:          p.IAttr == 1,
+			Underline:       p.UAttr == 1,
+			Color:           color(p.FcForeColor),
+			BackgroundColor: color(p.BcBackColor),
+		}
+	}
+	var cues []Cue
+	for _, event := range document.Events {
+		// appended events only carry the line breaks between the lines of the previous events
+		if event.AAppend == 1 || len(event.Segs) == 0 {
+			continue
+		}
+		cue := Cue{
+			Start: time.Duration(event.TStartMs) * time.Millisecond,
+			End:   time.Duration(event.TStartMs+event.DDurationMs) * time.Millisecond,
+		}
+		if id := event.WpWinPosID; id != nil && *id >= 0 && *id < len(document.WpWinPositions) {
+			wp := document.WpWinPositions[*id]
+			cue.Position = position(wp.ApPoint, wp.AhHorPos, wp.AvVerPos)
+		}
+		for _, seg := range event.Segs {
+			id := seg.PPenID
+			if id == nil {
+				id = event.PPenID
+			}
+			cue.Segments = append(cue.Segments, Segment{
+				Offset: time.Duration(seg.TOffsetMs) * time.Millisecond,
+				Text:   seg.UTF8,
+				Style:  pen(id),
+			})
+		}
+		if cue = cue.withText(); cue.Text != "" {
+			cues = append(cues, cue)
+		}
+	}
+	return cues
+}
+
+// withText sets the text of the cue to the joined text of its segments.
+func (cue Cue) withText() Cue {
+	var b strings.Builder
+	for _, segment := range cue.Segments {
+		b.WriteString(segment.Text)
+	}
+	cue.Text = strings.TrimSpace(b.String())
+	return cue
+}
@@ -0,0 +1,104 @@
+package caption
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const srv3Sample = `<?xml version="1.0" encoding="utf-8" ?><timedtext format="3">
+<head>
+<pen id="1" b="1" fc="#fee600"/>
+<pen id="2" i="1" bc="#080808"/>
+<wp id="1" ap="0" ah="10" av="5"/>
+</head>
+<body>
+<p t="1000" d="2500" wp="1" p="1">Top &amp; left</p>
+<p t="4000" d="3000"><s>never</s><s t="500"> gonna</s><s t="1200" p="2"> give</s></p>
+<p t="8000" d="1000"> </p>
+</body>
+</timedtext>`
+
+const json3Sample = `{"wireMagic":"pb3",
+"pens":[{},{"bAttr":1,"fcForeColor":16705024},{"iAttr":1,"bcBackColor":526344}],
+"wpWinPositions":[{},{"apPoint":0,"ahHorPos":10,"avVerPos":5}],
+"events":[
+{"tStartMs":0,"dDurationMs":9000,"id":1,"wpWinPosId":1},
+{"tStartMs":1000,"dDurationMs":2500,"wpWinPosId":1,"pPenId":1,"segs":[{"utf8":"Top & left"}]},
+{"tStartMs":4000,"dDurationMs":3000,"wpWinPosId":0,"segs":[{"utf8":"never"},{"utf8":" gonna","tOffsetMs":500},{"utf8":" give","tOffsetMs":1200,"pPenId":2}]},
+{"tStartMs":6999,"dDurationMs":1,"aAppend":1,"segs":[{"utf8":"\n"}]}
+]}`
+
+// checkStyledCues checks the cues parsed from the samples above, which describe the same caption.
+func checkStyledCues(t *testing.T, cues []Cue) {
+	t.Helper()
+	if len(cues) != 2 {
+		t.Fatalf("got %d cues, want 2", len(cues))
+	}
+	first, second := cues[0], cues[1]
+	if first.Start != ms(1000) || first.End != ms(3500) || first.Text != "Top & left" {
+		t.Errorf("first cue = %v-%v %q", first.Start, first.End, first.Text)
+	}
+	if first.Position == nil || *first.Position != (Position{Anchor: 0, Horizontal: 10, Vertical: 5}) {
+		t.Errorf("first cue position = %+v", first.Position)
+	}
+	if style := first.Segments[0].Style; style != (Style{Bold: true, Color: "#FEE600"}) {
+		t.Errorf("first cue style = %+v", style)
+	}
+	if second.Text != "never gonna give" || second.Position != nil || len(second.Segments) != 3 {
+		t.Fatalf("second cue = %q at %+v with %d segments", second.Text, second.Position, len(second.Segments))
+	}
+	if offset := second.Segments[2].Offset; offset != ms(1200) {
+		t.Errorf("third segment offset = %v, want 1.2s", offset)
+	}
+	if style := second.Segments[2].Style; style != (Style{Italic: true, BackgroundColor: "#080808"}) {
+		t.Errorf("third segment style = %+v", style)
+	}
+}
+
+func TestParseSRV3(t *testing.T) {
+	cues, err := ParseSRV3([]byte(srv3Sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStyledCues(t, cues)
+	if _, err = ParseSRV3([]byte("<timedtext><body><p>")); err == nil {
+		t.Error("expected an error for a truncated document")
+	}
+}
+
+func TestParseJSON3(t *testing.T) {
+	cues, err := ParseJSON3([]byte(json3Sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStyledCues(t, cues)
+	if _, err = ParseJSON3([]byte(`{"events":[`)); err == nil {
+		t.Error("expected an error for a truncated document")
+	}
+}
+
+func TestWriteASS(t *testing.T) {
+	cues, err := ParseJSON3([]byte(json3Sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err = WriteASS(&b, cues); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	want := []string{
+		`Dialogue: 0,0:00:01.00,0:00:03.50,Default,,0,0,0,,{\an7\pos(38,14)}{\b1\i0\u0\c&H00E6FE&}Top & left`,
+		`Dialogue: 0,0:00:04.00,0:00:07.00,Box,,0,0,0,,{\k50\b0\i0\u0\c\3c}never{\k70\b0\i0\u0\c\3c} gonna{\k180\b0\i1\u0\c\3c&H080808&} give`,
+	}
+	got := lines[len(lines)-len(want):]
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got\n%v\nwant\n%v", got[i], want[i])
+		}
+	}
+	if !strings.Contains(b.String(), "\nStyle: Box,") {
+		t.Error("the Box style is missing")
+	}
+}
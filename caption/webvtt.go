@@ -0,0 +1,305 @@
+package caption
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Normalize returns a copy of `cues` sorted by start time, without empty cues and overlapping cues,
+// which is suitable for formats that do not allow cues to overlap.
+// Overlapping cues are cut where they start and end, and the parts showing at the same time are merged into one cue,
+// with their texts joined by line breaks (in the order they start) and the position of the first one.
+// No text is lost: e.g. the cues '1-4 A' and '2-3 B' become '1-2 A', '2-3 A\nB' and '3-4 A'.
+func Normalize(cues []Cue) []Cue {
+	sorted := make([]Cue, 0, len(cues))
+	var bounds []time.Duration
+	for _, cue := range cues {
+		if cue.Text = strings.TrimSpace(cue.Text); cue.Text == "" {
+			continue
+		}
+		if cue.End < cue.Start {
+			cue.End = cue.Start
+		}
+		sorted = append(sorted, cue)
+		bounds = append(bounds, cue.Start, cue.End)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+	sort.Slice(bounds, func(i, j int) bool {
+		return bounds[i] < bounds[j]
+	})
+	unique := bounds[:0]
+	for _, bound := range bounds {
+		if len(unique) == 0 || bound != unique[len(unique)-1] {
+			unique = append(unique, bound)
+		}
+	}
+	bounds = unique
+
+	var results []Cue
+	var active, previous []int // the indices of the cues showing in the current and the previous part
+	next := 0
+	for i, from := range bounds {
+		kept := active[:0]
+		for _, j := range active {
+			if sorted[j].End > from {
+				kept = append(kept, j)
+			}
+		}
+		active = kept
+		for ; next < len(sorted) && sorted[next].Start <= from; next++ {
+			if sorted[next].End > from {
+				active = append(active, next)
+			} else {
+				// a cue without duration is kept as is
+				results = append(results, sorted[next])
+				previous = nil
+			}
+		}
+		if len(active) == 0 || i+1 == len(bounds) {
+			previous = nil
+			continue
+		}
+		to := bounds[i+1]
+		if n := len(results); n > 0 && sameIndices(active, previous) {
+			results[n-1].End = to
+			continue
+		}
+		results = append(results, mergeCues(sorted, active, from, to))
+		previous = append([]int(nil), active...)
+	}
+	return results
+}
+
+func sameIndices(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeCues returns the cue showing the cues of the given indices from `from` to `to`.
+// The offsets of the segments are made relative to `from`.
+func mergeCues(cues []Cue, indices []int, from, to time.Duration) Cue {
+	merged := Cue{Start: from, End: to, Position: cues[indices[0]].Position}
+	var texts []string
+	hasSegments := false
+	for _, i := range indices {
+		texts = append(texts, cues[i].Text)
+		hasSegments = hasSegments || cues[i].Segments != nil
+	}
+	merged.Text = strings.Join(texts, "\n")
+	if !hasSegments {
+		return merged
+	}
+	for n, i := range indices {
+		if n > 0 {
+			merged.Segments = append(merged.Segments, Segment{Text: "\n"})
+		}
+		shift := from - cues[i].Start
+		for _, segment := range cues[i].segments() {
+			if segment.Offset -= shift; segment.Offset < 0 {
+				segment.Offset = 0
+			}
+			merged.Segments = append(merged.Segments, segment)
+		}
+	}
+	return merged
+}
+
+// segments returns the segments of the cue, or its whole text as a single segment if it has none.
+func (cue Cue) segments() []Segment {
+	if cue.Segments != nil {
+		return cue.Segments
+	}
+	return []Segment{{Text: cue.Text}}
+}
+
+// vttSettings returns the cue settings placing the cue at its position, or an empty string for the default position.
+func vttSettings(p *Position) string {
+	if p == nil {
+		return ""
+	}
+	align := [3]string{"left", "center", "right"}[p.Anchor%3]
+	positionAlign := [3]string{"line-left", "center", "line-right"}[p.Anchor%3]
+	lineAlign := [3]string{"start", "center", "end"}[p.Anchor/3%3]
+	return fmt.Sprintf(" position:%d%%,%v line:%d%%,%v align:%v", p.Horizontal, positionAlign, p.Vertical, lineAlign, align)
+}
+
+func colorClass(prefix, color string) string {
+	return prefix + strings.TrimPrefix(color, "#")
+}
+
+// vttPayload returns the text of the cue with its styling as tags and its word-level timing as timestamps.
+func vttPayload(cue Cue) string {
+	text := vttEscaper.Replace(cue.Text)
+	if cue.styled() {
+		var b strings.Builder
+		for _, segment := range cue.Segments {
+			if at := cue.Start + segment.Offset; at > cue.Start && at < cue.End {
+				b.WriteString("<" + clock(at, ".") + ">")
+			}
+			var open, close []string
+			if style := segment.Style; style != (Style{}) {
+				var classes string
+				if style.Color != "" {
+					classes += "." + colorClass("fg", style.Color)
+				}
+				if style.BackgroundColor != "" {
+					classes += "." + colorClass("bg", style.BackgroundColor)
+				}
+				if classes != "" {
+					open, close = append(open, "<c"+classes+">"), append(close, "</c>")
+				}
+				for _, tag := range []struct {
+					name string
+					on   bool
+				}{{"b", style.Bold}, {"i", style.Italic}, {"u", style.Underline}} {
+					if tag.on {
+						open, close = append(open, "<"+tag.name+">"), append([]string{"</" + tag.name + ">"}, close...)
+					}
+				}
+			}
+			b.WriteString(strings.Join(open, "") + vttEscaper.Replace(segment.Text) + strings.Join(close, ""))
+		}
+		text = b.String()
+	}
+	// blank lines would end the cue early
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WriteWebVTT writes the cues in WebVTT format, normalizing them first (see `Normalize()`).
+// The styling of the segments is written as tags (and the colors as classes defined in a STYLE block),
+// the word-level timing as timestamps and the positions as cue settings.
+func WriteWebVTT(w io.Writer, cues []Cue) error {
+	cues = Normalize(cues)
+	var styles []string
+	defined := make(map[string]bool)
+	for _, cue := range cues {
+		for _, segment := range cue.Segments {
+			for property, color := range map[string]string{"fg": segment.Style.Color, "bg": segment.Style.BackgroundColor} {
+				if class := colorClass(property, color); color != "" && !defined[class] {
+					defined[class] = true
+					name := "color"
+					if property == "bg" {
+						name = "background-color"
+					}
+					styles = append(styles, fmt.Sprintf("::cue(.%v) { %v: %v; }", class, name, color))
+				}
+			}
+		}
+	}
+	sort.Strings(styles)
+	if _, err := io.WriteString(w, "WEBVTT\n"); err != nil {
+		return err
+	}
+	if len(styles) > 0 {
+		if _, err := fmt.Fprintf(w, "\nSTYLE\n%v\n", strings.Join(styles, "\n")); err != nil {
+			return err
+		}
+	}
+	for _, cue := range cues {
+		if _, err := fmt.Fprintf(w, "\n%v --> %v%v\n%v\n", clock(cue.Start, "."), clock(cue.End, "."), vttSettings(cue.Position), vttPayload(cue)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var vttTimingPattern = regexp.MustCompile(`^((?:\d{2,}:)?[0-5]\d:[0-5]\d\.\d{3})[ \t]+-->[ \t]+((?:\d{2,}:)?[0-5]\d:[0-5]\d\.\d{3})(?:[ \t].*)?$`)
+
+// ParseWebVTT parses the cues of a WebVTT document, failing on anything that does not follow the specification.
+// NOTE, STYLE and REGION blocks are skipped, cue settings are ignored and the text of the cues is unescaped.
+func ParseWebVTT(r io.Reader) ([]Cue, error) {
+	var blocks [][]string
+	var block []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			if block != nil {
+				blocks = append(blocks, block)
+				block = nil
+			}
+			continue
+		}
+		block = append(block, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if block != nil {
+		blocks = append(blocks, block)
+	}
+
+	header := ""
+	if len(blocks) > 0 {
+		header = strings.TrimPrefix(blocks[0][0], "\ufeff")
+	}
+	if header != "WEBVTT" && !strings.HasPrefix(header, "WEBVTT ") && !strings.HasPrefix(header, "WEBVTT\t") {
+		return nil, fmt.Errorf("webvtt: missing 'WEBVTT' header")
+	}
+	var cues []Cue
+	for _, block := range blocks[1:] {
+		if block[0] == "NOTE" || strings.HasPrefix(block[0], "NOTE ") || block[0] == "STYLE" || block[0] == "REGION" {
+			continue
+		}
+		// the cue identifier is optional
+		if !strings.Contains(block[0], "-->") {
+			block = block[1:]
+		}
+		if len(block) == 0 {
+			return nil, fmt.Errorf("webvtt: cue without timings")
+		}
+		matches := vttTimingPattern.FindStringSubmatch(block[0])
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("webvtt: invalid cue timings '%v'", block[0])
+		}
+		start, end := parseTimestamp(matches[1]), parseTimestamp(matches[2])
+		if end < start {
+			return nil, fmt.Errorf("webvtt: cue ends before it starts '%v'", block[0])
+		}
+		for _, line := range block[1:] {
+			if strings.Contains(line, "-->") {
+				return nil, fmt.Errorf("webvtt: cue text contains '-->' '%v'", line)
+			}
+		}
+		cues = append(cues, Cue{Start: start, End: end, Text: html.UnescapeString(strings.Join(block[1:], "\n"))})
+	}
+	return cues, nil
+}
+
+// parseTimestamp parses a timestamp already validated by `vttTimingPattern`.
+func parseTimestamp(s string) time.Duration {
+	var d time.Duration
+	parts := strings.Split(s, ":")
+	for _, part := range parts[:len(parts)-1] {
+		n, _ := strconv.Atoi(part)
+		d = d*60 + time.Duration(n)
+	}
+	secs := strings.Split(parts[len(parts)-1], ".")
+	n, _ := strconv.Atoi(secs[0])
+	ms, _ := strconv.Atoi(secs[1])
+	return (d*60+time.Duration(n))*time.Second + time.Duration(ms)*time.Millisecond
+}
@@ -0,0 +1,98 @@
+package caption
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ms(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+
+func cuesString(cues []Cue) string {
+	var lines []string
+	for _, cue := range cues {
+		lines = append(lines, fmt.Sprintf("%v-%v %q", cue.Start.Milliseconds(), cue.End.Milliseconds(), cue.Text))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		cues []Cue
+		want []Cue
+	}{
+		{
+			"overlapping",
+			[]Cue{{Start: ms(1000), End: ms(4000), Text: "A"}, {Start: ms(2000), End: ms(3000), Text: "B"}},
+			[]Cue{{Start: ms(1000), End: ms(2000), Text: "A"}, {Start: ms(2000), End: ms(3000), Text: "A\nB"}, {Start: ms(3000), End: ms(4000), Text: "A"}},
+		},
+		{
+			"rolling",
+			[]Cue{{Start: ms(0), End: ms(2000), Text: "one"}, {Start: ms(1000), End: ms(3000), Text: "two"}, {Start: ms(2000), End: ms(4000), Text: "three"}},
+			[]Cue{{Start: ms(0), End: ms(1000), Text: "one"}, {Start: ms(1000), End: ms(2000), Text: "one\ntwo"}, {Start: ms(2000), End: ms(3000), Text: "two\nthree"}, {Start: ms(3000), End: ms(4000), Text: "three"}},
+		},
+		{
+			"same start",
+			[]Cue{{Start: ms(0), End: ms(1000), Text: "A"}, {Start: ms(0), End: ms(2000), Text: "B"}},
+			[]Cue{{Start: ms(0), End: ms(1000), Text: "A\nB"}, {Start: ms(1000), End: ms(2000), Text: "B"}},
+		},
+		{
+			"unsorted, empty and without duration",
+			[]Cue{{Start: ms(3000), End: ms(4000), Text: "C"}, {Start: ms(500), End: ms(100), Text: "instant"}, {Start: ms(0), End: ms(1000), Text: " "}, {Start: ms(1000), End: ms(2000), Text: " A "}},
+			[]Cue{{Start: ms(500), End: ms(500), Text: "instant"}, {Start: ms(1000), End: ms(2000), Text: "A"}, {Start: ms(3000), End: ms(4000), Text: "C"}},
+		},
+		{
+			"adjacent",
+			[]Cue{{Start: ms(0), End: ms(1000), Text: "A"}, {Start: ms(1000), End: ms(2000), Text: "A"}},
+			[]Cue{{Start: ms(0), End: ms(1000), Text: "A"}, {Start: ms(1000), End: ms(2000), Text: "A"}},
+		},
+	}
+	for _, test := range tests {
+		if got, want := cuesString(Normalize(test.cues)), cuesString(test.want); got != want {
+			t.Errorf("%v:\ngot\n%v\nwant\n%v", test.name, got, want)
+		}
+	}
+}
+
+func TestNormalizeSegments(t *testing.T) {
+	cues := Normalize([]Cue{
+		{Start: ms(0), End: ms(3000), Text: "hello world", Segments: []Segment{{Text: "hello "}, {Offset: ms(1500), Text: "world", Style: Style{Bold: true}}}},
+		{Start: ms(1000), End: ms(2000), Text: "B"},
+	})
+	if len(cues) != 3 {
+		t.Fatalf("got %d cues, want 3", len(cues))
+	}
+	middle := cues[1].Segments
+	if len(middle) != 4 || middle[1].Offset != ms(500) || !middle[1].Style.Bold || middle[2].Text != "\n" || middle[3].Text != "B" {
+		t.Errorf("got segments %+v", middle)
+	}
+	if last := cues[2].Segments; len(last) != 2 || last[0].Offset != 0 || last[1].Offset != 0 {
+		t.Errorf("got segments %+v, want the offsets clamped to the start", last)
+	}
+}
+
+func TestWebVTTRoundTrip(t *testing.T) {
+	cues := []Cue{
+		{Start: ms(0), End: ms(1500), Text: "Tom & Jerry <3"},
+		{Start: ms(1000), End: ms(2500), Text: "a > b\nand b < c"},
+		{Start: ms(3723456), End: ms(3725000), Text: "&amp; is not unescaped twice"},
+	}
+	var b bytes.Buffer
+	if err := WriteWebVTT(&b, cues); err != nil {
+		t.Fatal(err)
+	}
+	content := b.String()
+	if !strings.HasPrefix(content, "WEBVTT") || !strings.Contains(content, "Tom &amp; Jerry &lt;3") || !strings.Contains(content, "01:02:03.456 --> 01:02:05.000") {
+		t.Errorf("unexpected content:\n%v", content)
+	}
+	parsed, err := ParseWebVTT(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cuesString(parsed), cuesString(Normalize(cues)); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+}
@@ -0,0 +1,101 @@
+package gotube
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCaptionSearchURL(t *testing.T) {
+	caption := &Caption{
+		watchURL: "https://youtube.com/watch?hl=en&v=dQw4w9WgXcQ",
+		contents: map[string]string{"": `<?xml version="1.0" encoding="utf-8" ?><transcript>` +
+			`<text start="1.5" dur="2">Never gonna give you up</text>` +
+			`<text start="83.9" dur="3.2">never gonna   let you down</text>` +
+			`<text start="90" dur="2">Never gonna run around</text></transcript>`},
+	}
+	matches, err := caption.Search("gonna let")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("got %d matches, want 1", len(matches))
+	}
+	m := matches[0]
+	if want := "https://youtube.com/watch?hl=en&v=dQw4w9WgXcQ&t=83s"; m.URL != want {
+		t.Errorf("URL = %q, want %q", m.URL, want)
+	}
+	if m.Start != 83900*time.Millisecond || m.Text != "never gonna let you down" {
+		t.Errorf("got %+v", m)
+	}
+
+	matches, err = caption.Search("never gonna")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"&t=1s", "&t=83s", "&t=90s"}
+	if len(matches) != len(want) {
+		t.Fatalf("got %d matches, want %d", len(matches), len(want))
+	}
+	for i, m := range matches {
+		if m.URL != caption.watchURL+want[i] {
+			t.Errorf("match %d: URL = %q, want suffix %q", i, m.URL, want[i])
+		}
+	}
+}
+
+func TestCaptionTranslate(t *testing.T) {
+	caption := &Caption{
+		watchURL:       "https://youtube.com/watch?hl=en&v=dQw4w9WgXcQ",
+		translations:   []TranslationLanguage{{LanguageCode: "fr", Name: "French"}, {LanguageCode: "de", Name: "German"}},
+		URL:            "https://www.youtube.com/api/timedtext?v=dQw4w9WgXcQ&lang=en",
+		Name:           "English",
+		LanguageCode:   "en",
+		IsTranslatable: true,
+	}
+	if _, err := caption.Translate("xx"); err == nil {
+		t.Error("Translate(xx): expected an error")
+	}
+	translated, err := caption.Translate("fr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if translated.watchURL != caption.watchURL {
+		t.Errorf("watchURL = %q, want %q", translated.watchURL, caption.watchURL)
+	}
+	if translated.LanguageCode != "fr" || translated.TranslatedFrom != "en" {
+		t.Errorf("got language %q from %q", translated.LanguageCode, translated.TranslatedFrom)
+	}
+	if got := len(translated.TranslationLanguages()); got != 2 {
+		t.Errorf("got %d translation languages, want 2", got)
+	}
+	again, err := translated.Translate("de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.TranslatedFrom != "en" || again.URL != "https://www.youtube.com/api/timedtext?lang=en&tlang=de&v=dQw4w9WgXcQ" {
+		t.Errorf("got %q from %q", again.URL, again.TranslatedFrom)
+	}
+
+	translated.contents = map[string]string{"": "<transcript></transcript>"}
+	path, err := translated.Save(t.TempDir(), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := filepath.Base(path); got != "fr.from.en.xml" {
+		t.Errorf("saved as %q, want %q", got, "fr.from.en.xml")
+	}
+}
+
+func TestCaptionSaveSanitized(t *testing.T) {
+	caption := &Caption{Name: "English / CC: auto", LanguageCode: "en",
+		contents: map[string]string{"": "<transcript></transcript>"}}
+	dir := t.TempDir()
+	path, err := caption.Save(dir, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "English _ CC_ auto.xml"); path != want {
+		t.Errorf("saved as %q, want %q", path, want)
+	}
+}
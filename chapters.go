@@ -0,0 +1,157 @@
+package gotube
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Chapters represents a sequence of chapters.
+type Chapters []*Chapter
+
+// Chapter represents a chapter of a YouTube video.
+type Chapter struct {
+	Title string        `json:"title"`
+	Start time.Duration `json:"start"`
+	// End is zero if the chapter lasts until the end of a video whose duration is unknown.
+	End time.Duration `json:"end"`
+}
+
+type chapterJSON Chapter
+
+// MarshalJSON marshals this chapter with its start and end in seconds.
+func (chapter Chapter) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		*chapterJSON
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+	}{(*chapterJSON)(&chapter), seconds(chapter.Start), seconds(chapter.End)})
+}
+
+// UnmarshalJSON unmarshals this chapter with its start and end in seconds (see `MarshalJSON()`).
+func (chapter *Chapter) UnmarshalJSON(b []byte) error {
+	v := struct {
+		*chapterJSON
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+	}{chapterJSON: (*chapterJSON)(chapter)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	chapter.Start, chapter.End = fromSeconds(v.Start), fromSeconds(v.End)
+	return nil
+}
+
+// At returns the `Chapter` which the given time of the video is in.
+func (chapters Chapters) At(t time.Duration) *Chapter {
+	for _, chapter := range chapters {
+		if t >= chapter.Start && (t < chapter.End || chapter.End == 0) {
+			return chapter
+		}
+	}
+	return nil
+}
+
+var ffmetadataEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n")
+
+// FFMetadata returns the chapters in the metadata format of ffmpeg ('FFMETADATA1'),
+// which can be embedded into a media file with `ffmpeg -i <media> -i <metadata> -map_chapters 1 ...`.
+func (chapters Chapters) FFMetadata() string {
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	for _, chapter := range chapters {
+		fmt.Fprintf(&b, "\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\n", chapter.Start.Milliseconds())
+		// ffmpeg ends a chapter without an end at the end of the media
+		if chapter.End != 0 {
+			fmt.Fprintf(&b, "END=%d\n", chapter.End.Milliseconds())
+		}
+		fmt.Fprintf(&b, "title=%v\n", ffmetadataEscaper.Replace(chapter.Title))
+	}
+	return b.String()
+}
+
+// Chapters retrieves the chapters of this video,
+// either from the chapter markers of the player or from the timestamps listed in the description.
+func (video *Video) Chapters() Chapters {
+	if video.playerResponse == nil {
+		panic("player response is nil: Initialize() must be called beforehand")
+	}
+	if video.chapters != nil {
+		return video.chapters
+	}
+	if video.chapters = video.markerChapters(video.Duration); video.chapters == nil {
+		video.chapters = parseChapters(video.Description, video.Duration)
+	}
+	return video.chapters
+}
+
+func (video *Video) markerChapters(duration time.Duration) Chapters {
+	if video.initialData == nil {
+		return nil
+	}
+	playerBar := video.initialData.PlayerOverlays.PlayerOverlayRenderer.DecoratedPlayerBarRenderer.DecoratedPlayerBarRenderer.PlayerBar
+	markers := playerBar.ChapteredPlayerBarRenderer.Chapters
+	for _, m := range playerBar.MultiMarkersPlayerBarRenderer.MarkersMap {
+		if len(m.Value.Chapters) > 0 {
+			markers = m.Value.Chapters
+			break
+		}
+	}
+	var chapters Chapters
+	for _, marker := range markers {
+		chapters = append(chapters, &Chapter{
+			Title: marker.ChapterRenderer.Title.SimpleText,
+			Start: time.Duration(marker.ChapterRenderer.TimeRangeStartMillis) * time.Millisecond,
+		})
+	}
+	return closeChapters(chapters, duration)
+}
+
+var chapterPattern = regexp.MustCompile(`^(.*?)\(?\b((?:\d{1,2}:)?\d{1,2}:\d{2})\b\)?(.*)$`)
+
+// parseChapters parses the chapters listed in a description, one per line with its start time (e.g. '1:23 Intro').
+// As YouTube does, the list must start at 0:00 and contain at least 3 chapters in ascending order.
+func parseChapters(description string, duration time.Duration) Chapters {
+	var chapters Chapters
+	for _, line := range strings.Split(description, "\n") {
+		matches := chapterPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if len(matches) == 0 {
+			continue
+		}
+		var start time.Duration
+		for _, part := range strings.Split(matches[2], ":") {
+			n, _ := strconv.Atoi(part)
+			start = start*60 + time.Duration(n)
+		}
+		start *= time.Second
+		if len(chapters) == 0 && start != 0 {
+			// the first chapter must start at 0:00, any timestamp before it is not part of the list
+			continue
+		}
+		if len(chapters) > 0 && start <= chapters[len(chapters)-1].Start {
+			break
+		}
+		title := strings.TrimSpace(matches[1] + " " + matches[3])
+		chapters = append(chapters, &Chapter{Title: strings.Trim(title, " \t-–—:|•·"), Start: start})
+	}
+	if len(chapters) < 3 {
+		return nil
+	}
+	return closeChapters(chapters, duration)
+}
+
+// closeChapters sets the end of each chapter to the start of the next one, and the end of the last one to `duration`,
+// which is left open (zero) if `duration` is unknown (zero).
+func closeChapters(chapters Chapters, duration time.Duration) Chapters {
+	for i, chapter := range chapters {
+		if i+1 < len(chapters) {
+			chapter.End = chapters[i+1].Start
+		} else {
+			chapter.End = duration
+		}
+	}
+	return chapters
+}
@@ -0,0 +1,46 @@
+package gotube
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseChapters(t *testing.T) {
+	description := "Tracklist:\n0:00 Intro\n1:23 - Verse\n(4:56) Chorus\n10:00 Outro"
+	chapters := parseChapters(description, 11*time.Minute)
+	want := Chapters{
+		{Title: "Intro", Start: 0, End: 83 * time.Second},
+		{Title: "Verse", Start: 83 * time.Second, End: 296 * time.Second},
+		{Title: "Chorus", Start: 296 * time.Second, End: 600 * time.Second},
+		{Title: "Outro", Start: 600 * time.Second, End: 11 * time.Minute},
+	}
+	if len(chapters) != len(want) {
+		t.Fatalf("got %d chapters, want %d", len(chapters), len(want))
+	}
+	for i, chapter := range chapters {
+		if *chapter != *want[i] {
+			t.Errorf("chapter %d = %+v, want %+v", i, *chapter, *want[i])
+		}
+	}
+	if chapters := parseChapters("0:00 Intro\n1:23 Verse", time.Minute); chapters != nil {
+		t.Errorf("got %d chapters from a list of 2, want none", len(chapters))
+	}
+}
+
+func TestChaptersUnknownDuration(t *testing.T) {
+	chapters := parseChapters("0:00 Intro\n1:00 Verse\n2:00 Outro", 0)
+	if len(chapters) != 3 {
+		t.Fatalf("got %d chapters, want 3", len(chapters))
+	}
+	if last := chapters[2]; last.End != 0 {
+		t.Errorf("last chapter ends at %v, want it open", last.End)
+	}
+	if chapter := chapters.At(time.Hour); chapter != chapters[2] {
+		t.Errorf("At(1h) = %+v, want the last chapter", chapter)
+	}
+	metadata := chapters.FFMetadata()
+	if strings.Count(metadata, "END=") != 2 || !strings.HasSuffix(metadata, "START=120000\ntitle=Outro\n") {
+		t.Errorf("unexpected metadata:\n%s", metadata)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/tnychn/gotube"
+	"github.com/tnychn/gotube/utils"
+)
+
+var (
+	lchapters     = dl.Flag("chapters", "List all chapters of the video.").Bool()
+	embedchapters = dl.Flag("embed-chapters", "Embed the chapters into the downloaded file as metadata (requires ffmpeg).").Bool()
+	splitchapters = dl.Flag("split-chapters", "Cut the downloaded file into one file per chapter (requires ffmpeg).").Bool()
+)
+
+func listChapters(chapters gotube.Chapters) {
+	if *jsonOut {
+		current.Chapters = chapters
+		return
+	}
+	_, _ = color.New(color.FgWhite, color.Bold).Printf("List Chapters: %s\n", color.HiBlackString("# chapters from the player or the description"))
+	if len(chapters) == 0 {
+		color.Yellow("  No chapters")
+		return
+	}
+	for i, chapter := range chapters {
+		end := "end"
+		if chapter.End != 0 {
+			end = timestamp(chapter.End)
+		}
+		color.HiCyan("  %2d. %s %s", i+1, color.WhiteString("%8s - %-8s", timestamp(chapter.Start), end), chapter.Title)
+	}
+}
+
+// processChapters embeds the chapters of `video` into the downloaded file at `path` and/or splits it by them.
+func processChapters(video *gotube.Video, path string) {
+	chapters := video.Chapters()
+	if len(chapters) == 0 {
+		printError(fmt.Errorf("video %v has no chapters", video.ID))
+		return
+	}
+	if *embedchapters {
+		status("# Embedding chapters...")
+		err := ffmpegEmbedChapters(path, chapters)
+		if *jsonOut {
+			current.addDownload(download{Kind: "chapters", Path: path}, err)
+		} else if err != nil {
+			printError(err)
+		} else {
+			_, _ = color.New(color.FgGreen, color.Bold).Printf("\r# Embedded %d Chapters %s\n", len(chapters), color.HiWhiteString(path))
+		}
+	}
+	if *splitchapters {
+		for i, chapter := range chapters {
+			status("\r# Splitting chapter %d/%d...", i+1, len(chapters))
+			chapterpath, err := ffmpegCut(path, i, chapter)
+			if *jsonOut {
+				current.addDownload(download{Kind: "chapter", Path: chapterpath}, err)
+			} else if err != nil {
+				printError(err)
+			}
+		}
+		if !*jsonOut {
+			_, _ = color.New(color.FgGreen, color.Bold).Printf("\r# Split into %d Chapters %s\n", len(chapters), color.HiWhiteString(filepath.Dir(path)))
+		}
+	}
+}
+
+// ffmpegEmbedChapters replaces the chapters of the file at `path` with `chapters`.
+func ffmpegEmbedChapters(path string, chapters gotube.Chapters) error {
+	bin, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return err
+	}
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	metapath := base + ".chapters.txt"
+	if err = ioutil.WriteFile(metapath, []byte(chapters.FFMetadata()), 0644); err != nil {
+		return err
+	}
+	defer os.Remove(metapath)
+	tmppath := base + ".chapters" + ext
+	cmd := exec.Command(bin, "-y", "-i", path, "-f", "ffmetadata", "-i", metapath,
+		"-map", "0", "-map_metadata", "0", "-map_chapters", "1", "-c", "copy", tmppath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		_ = os.Remove(tmppath)
+		return fmt.Errorf("ffmpeg: %v: %s", err, lastLine(out))
+	}
+	return os.Rename(tmppath, path)
+}
+
+// ffmpegCut copies the part of the file at `path` within `chapter` into a new file named after the chapter.
+func ffmpegCut(path string, index int, chapter *gotube.Chapter) (chapterpath string, err error) {
+	bin, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return
+	}
+	ext := filepath.Ext(path)
+	chapterpath = fmt.Sprintf("%s - %02d - %s%s", strings.TrimSuffix(path, ext), index+1, utils.SanitizeFilename(chapter.Title), ext)
+	args := []string{"-y", "-i", path, "-ss", seconds(chapter.Start)}
+	if chapter.End != 0 {
+		args = append(args, "-to", seconds(chapter.End))
+	}
+	args = append(args, "-map", "0", "-map_chapters", "-1", "-c", "copy", chapterpath)
+	if out, err := exec.Command(bin, args...).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("ffmpeg: %v: %s", err, lastLine(out))
+	}
+	return
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/url"
+	"os"
+
+	"github.com/fatih/color"
+
+	"github.com/tnychn/gotube"
+	"github.com/tnychn/gotube/fixture"
+)
+
+var (
+	fixtureCmd          = app.Command("fixture", "Capture or verify decryption fixtures for regression testing.")
+	fixtureDir          = fixtureCmd.Flag("dir", "Fixtures directory.").Default("fixture/testdata").String()
+	fixtureCapture      = fixtureCmd.Command("capture", "Capture a new fixture from the player of the given video.")
+	fixtureCaptureIdurl = fixtureCapture.Arg("idurl", "Target video ID or video URL.").Required().String()
+	fixtureCaptureName  = fixtureCapture.Flag("name", "Name of the fixture (defaults to the player version).").String()
+	fixtureVerify       = fixtureCmd.Command("verify", "Verify all saved fixtures against the current implementation.")
+)
+
+func captureFixture() {
+	_, _ = color.New(color.FgHiBlack).Print("# Loading Video...")
+	video, err := gotube.NewVideo(*fixtureCaptureIdurl, true)
+	if err != nil {
+		printError(err)
+		return
+	}
+	// collect the encrypted signatures of the streams as samples
+	var signatures []string
+	seen := make(map[string]bool)
+	for _, stream := range video.Streams() {
+		query, err := url.ParseQuery(stream.Metadata()["cipher"].(string))
+		if err != nil {
+			continue
+		}
+		if s := query.Get("s"); s != "" && !seen[s] {
+			seen[s] = true
+			signatures = append(signatures, s)
+		}
+	}
+	pageURL := video.WatchURL
+	if video.IsAgeRestricted {
+		pageURL = video.EmbedURL
+	}
+	_, _ = color.New(color.FgHiBlack).Print("\r# Capturing Fixture...")
+	f, err := fixture.Capture(*fixtureDir, *fixtureCaptureName, pageURL, signatures)
+	if err != nil {
+		printError(err)
+		return
+	}
+	if len(signatures) == 0 {
+		color.Yellow("\r# No encrypted streams found, only the transform plan will be verified")
+	}
+	_, _ = color.New(color.FgGreen, color.Bold).Printf("\r# Captured Fixture %s (%d signatures)\n", color.HiWhiteString(f.Dir), len(f.Expected.Signatures))
+}
+
+func verifyFixtures() {
+	fixtures, err := fixture.LoadAll(*fixtureDir)
+	if err != nil {
+		printError(err)
+		return
+	}
+	if len(fixtures) == 0 {
+		color.Yellow("No fixtures found in %s", *fixtureDir)
+		return
+	}
+	failed := 0
+	for _, f := range fixtures {
+		if err := f.Verify(); err != nil {
+			failed++
+			printError(err)
+			continue
+		}
+		color.Green("✔ %s", f.Name)
+	}
+	if failed > 0 {
+		color.Red("%d of %d fixtures failed", failed, len(fixtures))
+		os.Exit(1)
+	}
+}
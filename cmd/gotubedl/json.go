@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fatih/color"
+
+	"github.com/tnychn/gotube"
+)
+
+var jsonOut = dl.Flag("json", "Print the results as JSON instead, one line per video (NDJSON).").Short('j').Bool()
+
+// result collects the output of processing a video, which is printed as a single JSON line in JSON mode.
+type result struct {
+	IDURL     string                   `json:"idurl"`
+	ID        string                   `json:"id,omitempty"`
+	Info      *gotube.VideoInfo        `json:"info,omitempty"`
+	Streams   []map[string]interface{} `json:"streams,omitempty"`
+	Captions  gotube.Captions          `json:"captions,omitempty"`
+	Chapters  gotube.Chapters          `json:"chapters,omitempty"`
+	Downloads []download               `json:"downloads,omitempty"`
+	Errors    []string                 `json:"errors,omitempty"`
+}
+
+type download struct {
+	Kind     string `json:"kind"`
+	Itag     int    `json:"itag,omitempty"`
+	Language string `json:"language,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// current is the result of the video being processed.
+var current *result
+
+func (r *result) addDownload(d download, err error) {
+	if err != nil {
+		d.Path = ""
+		d.Error = err.Error()
+	}
+	r.Downloads = append(r.Downloads, d)
+}
+
+func printResult(r *result) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(b))
+}
+
+// streamJSON returns the fields of `stream` (according to their JSON tags) along with its itag and types.
+func streamJSON(stream gotube.Stream) map[string]interface{} {
+	m := stream.Metadata()
+	delete(m, "rawurl")
+	delete(m, "cipher")
+	m["itag"] = stream.Itag()
+	m["type"] = stream.Type()
+	m["subtype"] = stream.Subtype()
+	return m
+}
+
+// status prints a progress message, which is omitted in JSON mode.
+func status(format string, a ...interface{}) {
+	if *jsonOut {
+		return
+	}
+	_, _ = color.New(color.FgHiBlack).Printf(format, a...)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -12,24 +13,44 @@ import (
 	"gopkg.in/alecthomas/kingpin.v2"
 
 	"github.com/tnychn/gotube"
+	"github.com/tnychn/gotube/cache"
+	"github.com/tnychn/gotube/caption"
+	"github.com/tnychn/gotube/utils"
 )
 
 var (
 	app         = kingpin.New("gotubedl", "A command-line YouTube video downloader powered by gotube.")
-	idurl       = app.Arg("idurl", "Target video ID or video URL.").String()
-	ls          = app.Flag("streams", "List all available streams of the video.").Short('s').Bool()
-	lc          = app.Flag("captions", "List all available captions of the video.").Short('c').Bool()
-	itag        = app.Flag("itag", "Download stream by the given itag.").Short('i').Uint()
-	best        = app.Flag("best", "Download best stream of the given type. [a | v | av | a+v]").Short('b').String()
-	lang        = app.Flag("lang", "Download caption with the given language code.").Short('l').String()
-	destdir     = app.Flag("dest", "Destination output directory.").Short('d').ExistingDir()
-	filename    = app.Flag("filename", "Destination video filename.").Short('f').String()
-	noprefermp4 = app.Flag("no-prefer-mp4", "Toggle preference to mp4 formats.").Short('n').Bool()
-	overwrite   = app.Flag("overwrite", "Overwrite existing file that has the same filename.").Short('o').Bool()
+	retries     = app.Flag("retries", "Maximum number of attempts of each HTTP request.").Default("3").Int()
+	proxies     = app.Flag("proxy", "Proxy url (http, https or socks5) or per-host rule (host=url|direct). Repeat to rotate (one proxy per video).").Strings()
+	sourceaddr  = app.Flag("source-address", "Local IP address to bind the outgoing connections to.").String()
+	cookies     = app.Flag("cookies", "Netscape-format cookie file to send cookies from.").ExistingFile()
+	cachedir    = app.Flag("cache-dir", "Directory to cache player responses in, until their stream urls expire.").String()
+	dl          = app.Command("download", "Retrieve and download a video.").Default()
+	idurls      = dl.Arg("idurl", "Target video IDs or video URLs.").Strings()
+	ls          = dl.Flag("streams", "List all available streams of the video.").Short('s').Bool()
+	lc          = dl.Flag("captions", "List all available captions of the video.").Short('c').Bool()
+	itag        = dl.Flag("itag", "Download stream by the given itag.").Short('i').Uint()
+	best        = dl.Flag("best", "Download best stream of the given type. [a | v | av | a+v]").Short('b').String()
+	format      = dl.Flag("format", "Download streams selected by the given expression (e.g. 'bestvideo[height<=1080]+bestaudio/best').").String()
+	lang        = dl.Flag("lang", "Download caption with the given language code (manual captions are preferred).").Short('l').String()
+	tfrom       = dl.Flag("translate-from", "Translate the caption of the given language code into --lang.").String()
+	capformat   = dl.Flag("caption-format", "Format to save the caption in. [xml | "+strings.Join(caption.Names(), " | ")+"]").Default("vtt").String()
+	capstyled   = dl.Flag("caption-styled", "Keep the styling, positions and word timing of the caption (vtt and ass).").Bool()
+	audiolang   = dl.Flag("audio-lang", "Download the audio track in the given language code (for a and a+v).").String()
+	destdir     = dl.Flag("dest", "Destination output directory.").Short('d').ExistingDir()
+	filename    = dl.Flag("filename", "Destination video filename.").Short('f').String()
+	noprefermp4 = dl.Flag("no-prefer-mp4", "Toggle preference to mp4 formats.").Short('n').Bool()
+	thumbnail   = dl.Flag("thumbnail", "Download the thumbnail of the video in the highest resolution.").Bool()
+	overwrite   = dl.Flag("overwrite", "Overwrite existing file that has the same filename.").Short('o').Bool()
 )
 
 func printError(err error) {
-	if e, is := err.(gotube.Error); is {
+	if *jsonOut {
+		current.Errors = append(current.Errors, err.Error())
+		return
+	}
+	var e gotube.Error
+	if errors.As(err, &e) {
 		color.Red("\r✘ %s: %v", e.Name(), err)
 	} else {
 		color.Red("\r✘ error: %v", err)
@@ -37,21 +58,25 @@ func printError(err error) {
 }
 
 func printVideo(video *gotube.Video) {
+	if *jsonOut {
+		current.Info = video.VideoInfo
+		return
+	}
 	printField := func(key string, value interface{}) {
 		color.HiCyan("  %-9s %v", key+":", color.WhiteString("%v", value))
 	}
 
-	convertSecsToMinSecs := func(secs int) string {
-		minutes := secs / 60
-		seconds := secs % 60
-		return fmt.Sprintf("%d:%d", minutes, seconds)
-	}
-
 	fmt.Printf("\r%s\n", strings.Repeat(" ", len("# Loading video...")))
 	printField("Title", video.Title)
 	printField("Channel", video.Author)
-	printField("Duration", convertSecsToMinSecs(video.Duration))
+	printField("Duration", timestamp(video.Duration))
+	if !video.PublishDate.IsZero() {
+		printField("Published", video.PublishDate.Format("2006-01-02"))
+	}
 	printField("Views", video.Views)
+	if video.LikeCount > 0 {
+		printField("Likes", video.LikeCount)
+	}
 	printField("Age18+", video.IsAgeRestricted)
 	printField("Unlisted", video.IsUnlisted)
 	fmt.Println()
@@ -69,17 +94,92 @@ func main() {
 	app.HelpFlag.Short('h')
 	app.Version("1.0.0")
 	app.Author("tnychn")
-	kingpin.MustParse(app.Parse(os.Args[1:]))
+	command := kingpin.MustParse(app.Parse(os.Args[1:]))
+
+	policy := utils.DefaultRetryPolicy
+	policy.MaxAttempts = *retries
+	gotube.SetRetryPolicy(policy)
 
-	if *idurl == "" {
+	if len(*proxies) > 0 || *sourceaddr != "" {
+		config := &gotube.ProxyConfig{LocalAddr: *sourceaddr}
+		for _, spec := range *proxies {
+			if err := config.ParseProxy(spec); err != nil {
+				app.Fatalf("invalid --proxy option: %v", err)
+			}
+		}
+		if err := gotube.SetProxy(config); err != nil {
+			app.Fatalf("%v", err)
+		}
+	}
+	if *cookies != "" {
+		if err := gotube.LoadCookieFile(*cookies); err != nil {
+			app.Fatalf("invalid --cookies option: %v", err)
+		}
+	}
+	if *cachedir != "" {
+		c, err := cache.NewDir(*cachedir)
+		if err != nil {
+			app.Fatalf("invalid --cache-dir option: %v", err)
+		}
+		gotube.SetCache(c)
+	}
+
+	switch command {
+	case fixtureCapture.FullCommand():
+		captureFixture()
+	case fixtureVerify.FullCommand():
+		verifyFixtures()
+	case transcriptCmd.FullCommand():
+		searchTranscripts()
+	default:
+		runDownload()
+	}
+}
+
+func runDownload() {
+	if len(*idurls) == 0 {
 		app.Fatalf("no idurl provided")
 	}
 	if *best != "" && (*best != "a" && *best != "v" && *best != "av" && *best != "a+v") {
 		app.Fatalf("invalid -b/--best option: %v", *best)
 	}
+	if *format != "" {
+		if *itag != 0 || *best != "" {
+			app.Fatalf("--format cannot be used with -i/--itag or -b/--best")
+		}
+		if _, err := gotube.ParseSelector(*format); err != nil {
+			app.Fatalf("invalid --format option: %v", err)
+		}
+	}
+	if *capformat != "xml" {
+		if _, err := caption.Lookup(*capformat); err != nil {
+			app.Fatalf("%v", err)
+		}
+	}
+	if (len(*embedsubs) > 0 || *embedchapters || *splitchapters) && *itag == 0 && *best == "" && *format == "" {
+		app.Fatalf("--embed-subs, --embed-chapters and --split-chapters require a stream to download (-i/--itag, -b/--best or --format)")
+	}
+	if *tfrom != "" && *lang == "" {
+		app.Fatalf("--translate-from requires -l/--lang")
+	}
+	if len(*idurls) > 1 && *filename != "" {
+		app.Fatalf("-f/--filename cannot be used with multiple videos")
+	}
+	for _, u := range *idurls {
+		current = &result{IDURL: u}
+		processVideo(u)
+		if *jsonOut {
+			printResult(current)
+		}
+	}
+}
 
-	_, _ = color.New(color.FgHiBlack).Print("# Loading Video...")
-	video, err := gotube.NewVideo(*idurl, true)
+func processVideo(idurl string) {
+	status("# Loading Video...")
+	video, err := gotube.NewVideo(idurl, true)
+	if video != nil {
+		current.ID = video.ID
+	}
 	if err != nil {
 		printError(err)
 		return
@@ -92,7 +192,16 @@ func main() {
 	if *lc {
 		listCaptions(video.Captions())
 	}
-	if *itag != 0 || *best != "" {
+	if *lchapters {
+		listChapters(video.Chapters())
+	}
+	if *storyboard {
+		downloadStoryboard(video)
+	}
+	if *thumbnail {
+		saveThumbnail(video)
+	}
+	if *itag != 0 || *best != "" || *format != "" {
 		streams := video.Streams()
 		var pendingStreams gotube.Streams
 		// select stream
@@ -101,6 +210,17 @@ func main() {
 			if s != nil {
 				pendingStreams = append(pendingStreams, s)
 			}
+		} else if *format != "" {
+			selected, err := streams.Select(*format)
+			if err != nil {
+				printError(err)
+				return
+			}
+			// merged streams are downloaded audio first
+			if len(selected) == 2 && selected[1].Type() == "audio" {
+				selected[0], selected[1] = selected[1], selected[0]
+			}
+			pendingStreams = selected
 		} else if *best != "" {
 			if !*noprefermp4 {
 				streams = streams.Filter(func(i int, stream gotube.Stream) bool {
@@ -109,7 +229,7 @@ func main() {
 			}
 			switch *best {
 			case "a":
-				s := streams.Audios().Best()
+				s := selectAudios(streams).Best()
 				if s != nil {
 					pendingStreams = append(pendingStreams, s)
 				}
@@ -125,13 +245,16 @@ func main() {
 				}
 			case "a+v":
 				s2 := streams.Videos().Best()
-				s1 := streams.Filter(func(i int, stream gotube.Stream) bool {
+				if s2 == nil {
+					break
+				}
+				s1 := selectAudios(streams.Filter(func(i int, stream gotube.Stream) bool {
 					if *noprefermp4 {
 						return true
 					}
 					return stream.Subtype() == s2.Subtype()
-				}).Audios().Best()
-				if s1 != nil && s2 != nil {
+				})).Best()
+				if s1 != nil {
 					pendingStreams = append(pendingStreams, s1, s2)
 				}
 			}
@@ -141,17 +264,44 @@ func main() {
 			if !*ls {
 				listStreams(pendingStreams)
 			}
+			var path string
 			if len(pendingStreams) == 1 {
-				_, _ = downloadStream(pendingStreams[0])
+				if p, err := downloadStream(pendingStreams[0], *filename); err == nil {
+					path = p
+				}
 			}
 			if len(pendingStreams) == 2 {
-				downloadStreams(pendingStreams...)
+				path = downloadStreams(pendingStreams...)
+			}
+			if path != "" && len(*embedsubs) > 0 {
+				if hasVideo(pendingStreams) {
+					embedCaptions(video, path)
+				} else if *jsonOut {
+					current.addDownload(download{Kind: "subtitles"}, fmt.Errorf("skipped: the downloaded file has no video"))
+				} else {
+					color.Yellow("# Skipped embedding subtitles: the downloaded file has no video")
+				}
+			}
+			if path != "" && (*embedchapters || *splitchapters) {
+				processChapters(video, path)
 			}
 		} else {
 			printError(fmt.Errorf("no matched stream"))
 		}
 	}
-	if *lang != "" {
+	if *lang != "" && *tfrom != "" {
+		caption := video.Captions().LanguageCode(*tfrom)
+		if caption == nil {
+			printError(fmt.Errorf("no caption with language code '%s' was found", *tfrom))
+			return
+		}
+		translated, err := caption.Translate(*lang)
+		if err != nil {
+			printError(err)
+			return
+		}
+		saveCaption(translated)
+	} else if *lang != "" {
 		captions := video.Captions()
 		caption := captions.LanguageCode(*lang)
 		if caption != nil {
@@ -162,6 +312,20 @@ func main() {
 	}
 }
 
+// selectAudios returns the audio streams of `streams` in the language given by --audio-lang, if any.
+func selectAudios(streams gotube.Streams) gotube.AudioStreams {
+	audios := streams.Audios()
+	if *audiolang == "" {
+		return audios
+	}
+	if selected := audios.Language(*audiolang); len(selected) > 0 {
+		return selected
+	}
+	printError(fmt.Errorf("no audio track with language code '%s' was found (available: %s)",
+		*audiolang, strings.Join(audios.Languages(), ", ")))
+	return nil
+}
+
 func listStreams(streams gotube.Streams) {
 	printField := func(key string, value interface{}) {
 		color.HiCyan("       %-11s %v", key+":", color.WhiteString("%v", value))
@@ -172,13 +336,36 @@ func listStreams(streams gotube.Streams) {
 			printField("ACodec", stream.AudioCodec)
 		}
 		printField("Quality", stream.QualityLabel)
+		printField("FPS", stream.FPS)
+		if stream.IsHDR() {
+			printField("Range", stream.DynamicRange)
+		}
+		if stream.Is360() {
+			printField("Projection", stream.ProjectionType)
+		}
 	}
 	printAudioStream := func(stream *gotube.AudioStream) {
 		printField("Codec", stream.Codec)
 		printField("Quality", stream.Quality.String())
 		printField("SampleRate", stream.SampleRate)
+		if stream.AudioTrackName != "" {
+			track := fmt.Sprintf("%s [%s]", stream.AudioTrackName, stream.Language)
+			if stream.IsDefaultTrack {
+				track += " (default)"
+			}
+			printField("Track", track)
+		}
+		if stream.IsDRC {
+			printField("DRC", stream.IsDRC)
+		}
 	}
 
+	if *jsonOut {
+		for _, stream := range streams {
+			current.Streams = append(current.Streams, streamJSON(stream))
+		}
+		return
+	}
 	_, _ = color.New(color.FgWhite, color.Bold).Printf("List Streams:\n")
 	if len(streams) == 0 {
 		color.Yellow("  No Data")
@@ -209,6 +396,10 @@ func listCaptions(captions gotube.Captions) {
 	printField := func(key string, value interface{}) {
 		color.HiCyan("      %s %v", key+":", color.WhiteString("%v", value))
 	}
+	if *jsonOut {
+		current.Captions = captions
+		return
+	}
 	_, _ = color.New(color.FgWhite, color.Bold).Printf("List Captions: %s\n", color.HiBlackString("# all available caption tracks"))
 	if len(captions) == 0 {
 		color.Yellow("  No Data")
@@ -216,12 +407,19 @@ func listCaptions(captions gotube.Captions) {
 		for _, caption := range captions {
 			color.Blue("  [%s]%s", caption.LanguageCode, "------------------------------")
 			printField("Name", caption.Name)
+			printField("Auto-generated", caption.IsAutoGenerated)
+			printField("Translatable", caption.IsTranslatable)
 		}
 	}
 	fmt.Println()
 }
 
-func downloadStream(stream gotube.Stream) (path string, err error) {
+func downloadStream(stream gotube.Stream, fname string) (path string, err error) {
+	if *jsonOut {
+		path, err = stream.Download(*destdir, fname, *overwrite, nil, nil)
+		current.addDownload(download{Kind: "stream", Itag: stream.Itag(), Path: path}, err)
+		return
+	}
 	filesize := int64(stream.Metadata()["file_size"].(float64))
 	bar := progressbar.NewOptions64(
 		filesize,
@@ -230,10 +428,6 @@ func downloadStream(stream gotube.Stream) (path string, err error) {
 		progressbar.OptionSetPredictTime(true),
 		progressbar.OptionSetWidth(35),
 	)
-	fname := *filename
-	if *best == "a+v" {
-		fname = ""
-	}
 	if path, err = stream.Download(*destdir, fname, *overwrite, nil,
 		func(written int64) {
 			_ = bar.Set64(written)
@@ -247,25 +441,33 @@ func downloadStream(stream gotube.Stream) (path string, err error) {
 	return
 }
 
-func downloadStreams(streams ...gotube.Stream) {
+// downloadStreams downloads the audio and the video streams and remuxes them, returning the path of the remuxed file.
+func downloadStreams(streams ...gotube.Stream) string {
 	var paths []string
 	for i, stream := range streams {
 		task := "Audio"
 		if i == 1 {
 			task = "Video"
 		}
-		_, _ = color.New(color.FgHiBlack).Printf("# Downloading %s...\n", task)
-		path, err := downloadStream(stream)
+		status("# Downloading %s...\n", task)
+		path, err := downloadStream(stream, "")
 		if err != nil {
-			return
+			return ""
 		}
 		paths = append(paths, path)
 	}
-	if finalpath, err := ffmpegRemux(paths, streams); err != nil {
+	finalpath, err := ffmpegRemux(paths, streams)
+	if *jsonOut {
+		current.addDownload(download{Kind: "remux", Path: finalpath}, err)
+	} else if err != nil {
 		printError(err)
 	} else {
 		_, _ = color.New(color.FgYellow).Printf("\r# Done. Enjoy the video! %s\n", color.HiWhiteString(finalpath))
 	}
+	if err != nil {
+		return ""
+	}
+	return finalpath
 }
 
 func ffmpegRemux(paths []string, streams gotube.Streams) (finalpath string, err error) {
@@ -282,7 +484,7 @@ func ffmpegRemux(paths []string, streams gotube.Streams) (finalpath string, err
 		ext = "mkv"
 	}
 	finalpath = filepath.Join(*destdir, fname+"."+ext)
-	_, _ = color.New(color.FgHiBlack).Print("# Remuxing...")
+	status("# Remuxing...")
 	cmd := exec.Command(bin, "-i", paths[0], "-i", paths[1], "-codec", "copy", finalpath)
 	if err = cmd.Run(); err != nil {
 		return
@@ -296,12 +498,45 @@ func ffmpegRemux(paths []string, streams gotube.Streams) (finalpath string, err
 }
 
 func saveCaption(caption *gotube.Caption) {
-	var path string
-	var err error
-	_, _ = color.New(color.FgHiBlack).Print("# Saving caption...")
-	if path, err = caption.Save(*destdir, "", true); err != nil {
+	status("# Saving caption...")
+	save := caption.Save
+	if *capstyled {
+		save = caption.SaveStyled
+	}
+	path, err := save(*destdir, "", *capformat)
+	if *jsonOut {
+		current.addDownload(download{Kind: "caption", Language: caption.LanguageCode, Path: path}, err)
+		return
+	}
+	if err != nil {
 		printError(err)
 		return
 	}
 	_, _ = color.New(color.FgGreen, color.Bold).Printf("\r# Saved Caption %s\n", color.HiWhiteString(path))
 }
+
+func saveThumbnail(video *gotube.Video) {
+	status("# Saving thumbnail...")
+	thumbnail, err := video.ProbeThumbnail()
+	if err != nil || (video.Thumbnails.Best() != nil && video.Thumbnails.Best().Width > thumbnail.Width) {
+		thumbnail = video.Thumbnails.Best()
+	}
+	if thumbnail == nil {
+		printError(err)
+		return
+	}
+	fname := *filename
+	if fname == "" {
+		fname = video.ID
+	}
+	path, err := thumbnail.Save(*destdir, fname)
+	if *jsonOut {
+		current.addDownload(download{Kind: "thumbnail", Path: path}, err)
+		return
+	}
+	if err != nil {
+		printError(err)
+		return
+	}
+	_, _ = color.New(color.FgGreen, color.Bold).Printf("\r# Saved Thumbnail (%dx%d) %s\n", thumbnail.Width, thumbnail.Height, color.HiWhiteString(path))
+}
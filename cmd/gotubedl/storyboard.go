@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+
+	"github.com/tnychn/gotube"
+)
+
+var (
+	storyboard      = dl.Flag("storyboard", "Download the storyboard (preview thumbnails) of the video as frames.").Bool()
+	storyboardLevel = dl.Flag("storyboard-level", "Level of the storyboard to download, -1 for the largest frames.").Default("-1").Int()
+)
+
+func downloadStoryboard(video *gotube.Video) {
+	sb, err := video.Storyboard()
+	if err != nil {
+		printError(err)
+		return
+	}
+	level := sb.Best()
+	if *storyboardLevel >= 0 {
+		if *storyboardLevel >= len(sb.Levels) {
+			printError(fmt.Errorf("storyboard level %d does not exist (%d levels)", *storyboardLevel, len(sb.Levels)))
+			return
+		}
+		level = sb.Levels[*storyboardLevel]
+	}
+	dir := filepath.Join(*destdir, video.ID+"_storyboard")
+	if err = os.MkdirAll(dir, os.ModePerm); err != nil {
+		printError(err)
+		return
+	}
+	frames, err := level.DownloadFrames(dir, func(frame gotube.StoryboardFrame) {
+		status("\r# Saving storyboard frame %d/%d...", frame.Index+1, level.Count)
+	})
+	if *jsonOut {
+		current.addDownload(download{Kind: "storyboard", Path: dir}, err)
+		return
+	}
+	if err != nil {
+		printError(err)
+		return
+	}
+	_, _ = color.New(color.FgGreen, color.Bold).Printf("\r# Saved %d Storyboard Frames (%dx%d) %s\n", len(frames), level.Width, level.Height, color.HiWhiteString(dir))
+}
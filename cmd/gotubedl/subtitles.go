@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/tnychn/gotube"
+)
+
+var (
+	embedsubs      = dl.Flag("embed-subs", "Embed the captions of the given language codes into the downloaded video as soft subtitles (requires ffmpeg).").Strings()
+	embedsubscodec = dl.Flag("embed-subs-codec", "Codec of the subtitles embedded into mp4 files (mov_text or wvtt).").Default("mov_text").Enum("mov_text", "wvtt")
+)
+
+// hasVideo reports whether any of `streams` has video, i.e. whether the file downloaded from them is a video.
+func hasVideo(streams gotube.Streams) bool {
+	for _, stream := range streams {
+		if strings.HasPrefix(stream.Type(), "video") {
+			return true
+		}
+	}
+	return false
+}
+
+// iso6392 maps the ISO 639-1 language codes to the ISO 639-2 ones, which the mp4 and mkv containers use.
+var iso6392 = map[string]string{
+	"af": "afr", "ar": "ara", "bg": "bul", "bn": "ben", "ca": "cat", "cs": "ces", "cy": "cym", "da": "dan",
+	"de": "deu", "el": "ell", "en": "eng", "es": "spa", "et": "est", "eu": "eus", "fa": "fas", "fi": "fin",
+	"fil": "fil", "fr": "fra", "ga": "gle", "gl": "glg", "gu": "guj", "he": "heb", "iw": "heb", "hi": "hin",
+	"hr": "hrv", "hu": "hun", "hy": "hye", "id": "ind", "is": "isl", "it": "ita", "ja": "jpn", "ka": "kat",
+	"kk": "kaz", "km": "khm", "kn": "kan", "ko": "kor", "lt": "lit", "lv": "lav", "mk": "mkd", "ml": "mal",
+	"mn": "mon", "mr": "mar", "ms": "msa", "my": "mya", "nb": "nob", "ne": "nep", "nl": "nld", "no": "nor",
+	"pa": "pan", "pl": "pol", "pt": "por", "ro": "ron", "ru": "rus", "si": "sin", "sk": "slk", "sl": "slv",
+	"sq": "sqi", "sr": "srp", "sv": "swe", "sw": "swa", "ta": "tam", "te": "tel", "th": "tha", "tr": "tur",
+	"uk": "ukr", "ur": "urd", "uz": "uzb", "vi": "vie", "zh": "zho", "zu": "zul",
+}
+
+// languageTag returns the ISO 639-2 code of the language of the given code (e.g. 'en', 'pt-BR', 'zh-Hans').
+func languageTag(lc string) string {
+	base := strings.ToLower(strings.SplitN(lc, "-", 2)[0])
+	if tag, ok := iso6392[base]; ok {
+		return tag
+	}
+	if len(base) == 3 {
+		return base
+	}
+	return "und"
+}
+
+// embedCaptions embeds the captions of the languages given by --embed-subs into the video file at `path`.
+func embedCaptions(video *gotube.Video, path string) {
+	var captions gotube.Captions
+	for _, lc := range *embedsubs {
+		caption := video.Captions().LanguageCode(lc)
+		if caption == nil {
+			printError(fmt.Errorf("no caption with language code '%s' was found", lc))
+			continue
+		}
+		captions = append(captions, caption)
+	}
+	if len(captions) == 0 {
+		return
+	}
+	status("# Embedding subtitles...")
+	finalpath, err := ffmpegEmbedSubtitles(path, captions)
+	if *jsonOut {
+		current.addDownload(download{Kind: "subtitles", Path: finalpath}, err)
+		return
+	}
+	if err != nil {
+		printError(err)
+		return
+	}
+	_, _ = color.New(color.FgGreen, color.Bold).Printf("\r# Embedded %d Subtitles %s\n", len(captions), color.HiWhiteString(finalpath))
+}
+
+// ffmpegEmbedSubtitles muxes `captions` into the video file at `path` as subtitle tracks tagged with their languages,
+// using the codec given by --embed-subs-codec in mp4 and WebVTT in mkv and webm. Other containers are converted into mkv.
+func ffmpegEmbedSubtitles(path string, captions gotube.Captions) (finalpath string, err error) {
+	bin, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return
+	}
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	codec := "webvtt"
+	switch ext {
+	case "mp4", "m4v", "mov":
+		// mov_text (tx3g) is supported by most players, wvtt keeps the styling and positions of WebVTT
+		if codec = "mov_text"; *embedsubscodec == "wvtt" {
+			codec = "webvtt"
+		}
+	case "mkv", "webm":
+	default:
+		ext = "mkv"
+	}
+	finalpath = base + "." + ext
+
+	args := []string{"-y", "-i", path}
+	var subpaths []string
+	defer func() {
+		for _, subpath := range subpaths {
+			_ = os.Remove(subpath)
+		}
+	}()
+	for i, caption := range captions {
+		subpath, err := caption.Save(filepath.Dir(path), filepath.Base(base)+".sub"+strconv.Itoa(i), "vtt")
+		if err != nil {
+			return "", err
+		}
+		subpaths = append(subpaths, subpath)
+		args = append(args, "-i", subpath)
+	}
+	args = append(args, "-map", "0")
+	for i := range captions {
+		args = append(args, "-map", strconv.Itoa(i+1))
+	}
+	args = append(args, "-c", "copy", "-c:s", codec)
+	for i, caption := range captions {
+		stream := "-metadata:s:s:" + strconv.Itoa(i)
+		args = append(args,
+			stream, "language="+languageTag(caption.LanguageCode),
+			stream, "title="+caption.Name,
+			stream, "handler_name="+caption.Name,
+		)
+	}
+	tmppath := base + ".subs." + ext
+	args = append(args, tmppath)
+	if out, err := exec.Command(bin, args...).CombinedOutput(); err != nil {
+		_ = os.Remove(tmppath)
+		return "", fmt.Errorf("ffmpeg: %v: %s", err, lastLine(out))
+	}
+	if err = os.Rename(tmppath, finalpath); err != nil {
+		return
+	}
+	if finalpath != path {
+		err = os.Remove(path)
+	}
+	return
+}
+
+func lastLine(out []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	return lines[len(lines)-1]
+}
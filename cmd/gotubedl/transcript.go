@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/tnychn/gotube"
+)
+
+var (
+	transcriptCmd    = app.Command("transcript", "Search the transcripts (captions) of videos for what was said.")
+	transcriptIdurls = transcriptCmd.Arg("idurl", "Target video IDs or video URLs.").Required().Strings()
+	transcriptGrep   = transcriptCmd.Flag("grep", "Text to search for (case-insensitive).").Short('g').Required().String()
+	transcriptRegexp = transcriptCmd.Flag("regexp", "Treat --grep as a regular expression.").Short('E').Bool()
+	transcriptLang   = transcriptCmd.Flag("lang", "Search the caption with the given language code (defaults to the first caption).").Short('l').String()
+)
+
+func searchTranscripts() {
+	var pattern *regexp.Regexp
+	if *transcriptRegexp {
+		var err error
+		if pattern, err = regexp.Compile("(?i)" + *transcriptGrep); err != nil {
+			app.Fatalf("invalid --grep option: %v", err)
+		}
+	}
+	total := 0
+	for _, idurl := range *transcriptIdurls {
+		_, _ = color.New(color.FgHiBlack).Print("# Loading Video...")
+		video, err := gotube.NewVideo(idurl, true)
+		fmt.Printf("\r%18s\r", "")
+		if err != nil {
+			printError(err)
+			continue
+		}
+		captions := video.Captions()
+		var caption *gotube.Caption
+		if *transcriptLang != "" {
+			caption = captions.LanguageCode(*transcriptLang)
+		} else if manual := captions.Manual(); len(manual) > 0 {
+			caption = manual[0]
+		} else if len(captions) > 0 {
+			caption = captions[0]
+		}
+		if caption == nil {
+			printError(fmt.Errorf("%v: no caption to search", video.ID))
+			continue
+		}
+		var matches []gotube.CaptionMatch
+		if pattern != nil {
+			matches, err = caption.SearchFunc(pattern.MatchString)
+		} else {
+			matches, err = caption.Search(*transcriptGrep)
+		}
+		if err != nil {
+			printError(err)
+			continue
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		total += len(matches)
+		_, _ = color.New(color.FgWhite, color.Bold).Printf("%s %s\n", video.Title, color.HiBlackString("[%s]", caption.LanguageCode))
+		for _, m := range matches {
+			fmt.Printf("  %s %s %s\n", color.HiCyanString("%8s", timestamp(m.Start)), m.Text, color.HiBlackString(m.URL))
+		}
+	}
+	if total == 0 {
+		color.Yellow("No matches found")
+	}
+}
+
+// timestamp formats `d` as '[H:]MM:SS'.
+func timestamp(d time.Duration) string {
+	secs := int(d.Seconds())
+	if secs >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+	}
+	return fmt.Sprintf("%02d:%02d", secs/60, secs%60)
+}
@@ -24,6 +24,23 @@ func (aq AudioQuality) String() (s string) {
 	return
 }
 
+type ByteRange struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type ColorInfo struct {
+	Primaries               string `json:"primaries"`
+	TransferCharacteristics string `json:"transferCharacteristics"`
+	MatrixCoefficients      string `json:"matrixCoefficients"`
+}
+
+type AudioTrack struct {
+	DisplayName    string `json:"displayName"`
+	ID             string `json:"id"`
+	AudioIsDefault bool   `json:"audioIsDefault"`
+}
+
 type StreamFormat struct {
 	Itag             int          `json:"itag"`
 	Cipher           string       `json:"cipher"`
@@ -42,6 +59,14 @@ type StreamFormat struct {
 	AudioSampleRate  string       `json:"audioSampleRate"`
 	AudioChannels    int          `json:"audioChannels"`
 	AudioQuality     AudioQuality `json:"audioQuality"`
+	Fps              int          `json:"fps"`
+	ColorInfo        ColorInfo    `json:"colorInfo"`
+	InitRange        ByteRange    `json:"initRange"`
+	IndexRange       ByteRange    `json:"indexRange"`
+	AudioTrack       AudioTrack   `json:"audioTrack"`
+	IsDrc            bool         `json:"isDrc"`
+	LoudnessDb       float64      `json:"loudnessDb"`
+	HighReplication  bool         `json:"highReplication"`
 }
 
 type VideoDetails struct {
@@ -100,7 +125,9 @@ type PlayerResponse struct {
 		PlayerCaptionsTracklistRenderer struct {
 			CaptionTracks []CaptionTrack `json:"captionTracks"`
 			AudioTracks   []struct {
-				CaptionTrackIndices []int `json:"captionTrackIndices"`
+				CaptionTrackIndices      []int  `json:"captionTrackIndices"`
+				DefaultCaptionTrackIndex int    `json:"defaultCaptionTrackIndex"`
+				AudioTrackID             string `json:"audioTrackId"`
 			} `json:"audioTracks"`
 			TranslationLanguages []struct {
 				LanguageCode string `json:"languageCode"`
@@ -112,7 +139,15 @@ type PlayerResponse struct {
 		} `json:"playerCaptionsTracklistRenderer"`
 	} `json:"captions"`
 	VideoDetails VideoDetails `json:"videoDetails"`
-	Microformat  struct {
+	Storyboards  struct {
+		PlayerStoryboardSpecRenderer struct {
+			Spec string `json:"spec"`
+		} `json:"playerStoryboardSpecRenderer"`
+		PlayerLiveStoryboardSpecRenderer struct {
+			Spec string `json:"spec"`
+		} `json:"playerLiveStoryboardSpecRenderer"`
+	} `json:"storyboards"`
+	Microformat struct {
 		PlayerMicroformatRenderer struct {
 			Embed struct {
 				IframeURL      string `json:"iframeUrl"`
@@ -121,11 +156,13 @@ type PlayerResponse struct {
 				Height         int    `json:"height"`
 				FlashSecureURL string `json:"flashSecureUrl"`
 			} `json:"embed"`
-			IsUnlisted       bool   `json:"isUnlisted"`
-			Category         string `json:"category"`
-			PublishDate      string `json:"publishDate"`
-			OwnerChannelName string `json:"ownerChannelName"`
-			UploadDate       string `json:"uploadDate"`
+			IsUnlisted         bool     `json:"isUnlisted"`
+			Category           string   `json:"category"`
+			PublishDate        string   `json:"publishDate"`
+			OwnerChannelName   string   `json:"ownerChannelName"`
+			UploadDate         string   `json:"uploadDate"`
+			OwnerProfileURL    string   `json:"ownerProfileUrl"`
+			AvailableCountries []string `json:"availableCountries"`
 		} `json:"playerMicroformatRenderer"`
 	} `json:"microformat"`
 }
@@ -140,3 +177,147 @@ type TranscriptText struct {
 	Duration float64 `xml:"dur,attr"`
 	Text     string  `xml:",chardata"`
 }
+
+type TimedText struct {
+	XMLName    xml.Name             `xml:"timedtext"`
+	Pens       []TimedTextPen       `xml:"head>pen"`
+	Positions  []TimedTextPosition  `xml:"head>wp"`
+	Paragraphs []TimedTextParagraph `xml:"body>p"`
+}
+
+type TimedTextPen struct {
+	ID              string `xml:"id,attr"`
+	Bold            string `xml:"b,attr"`
+	Italic          string `xml:"i,attr"`
+	Underline       string `xml:"u,attr"`
+	ForeColor       string `xml:"fc,attr"`
+	BackgroundColor string `xml:"bc,attr"`
+}
+
+type TimedTextPosition struct {
+	ID         string `xml:"id,attr"`
+	Anchor     *int   `xml:"ap,attr"`
+	Horizontal *int   `xml:"ah,attr"`
+	Vertical   *int   `xml:"av,attr"`
+}
+
+type TimedTextParagraph struct {
+	Start    int64              `xml:"t,attr"`
+	Duration int64              `xml:"d,attr"`
+	Pen      string             `xml:"p,attr"`
+	Position string             `xml:"wp,attr"`
+	Append   string             `xml:"a,attr"`
+	Segments []TimedTextSegment `xml:"s"`
+	Text     string             `xml:",chardata"`
+}
+
+type TimedTextSegment struct {
+	Offset int64  `xml:"t,attr"`
+	Pen    string `xml:"p,attr"`
+	Text   string `xml:",chardata"`
+}
+
+type JSON3 struct {
+	Pens []struct {
+		BAttr       int  `json:"bAttr"`
+		IAttr       int  `json:"iAttr"`
+		UAttr       int  `json:"uAttr"`
+		FcForeColor *int `json:"fcForeColor"`
+		BcBackColor *int `json:"bcBackColor"`
+	} `json:"pens"`
+	WpWinPositions []struct {
+		ApPoint  *int `json:"apPoint"`
+		AhHorPos *int `json:"ahHorPos"`
+		AvVerPos *int `json:"avVerPos"`
+	} `json:"wpWinPositions"`
+	Events []struct {
+		TStartMs    int64 `json:"tStartMs"`
+		DDurationMs int64 `json:"dDurationMs"`
+		WpWinPosID  *int  `json:"wpWinPosId"`
+		PPenID      *int  `json:"pPenId"`
+		AAppend     int   `json:"aAppend"`
+		Segs        []struct {
+			UTF8      string `json:"utf8"`
+			TOffsetMs int64  `json:"tOffsetMs"`
+			PPenID    *int   `json:"pPenId"`
+		} `json:"segs"`
+	} `json:"events"`
+}
+
+type Chapter struct {
+	ChapterRenderer struct {
+		Title struct {
+			SimpleText string `json:"simpleText"`
+		} `json:"title"`
+		TimeRangeStartMillis int64 `json:"timeRangeStartMillis"`
+	} `json:"chapterRenderer"`
+}
+
+type ToggleButtonRenderer struct {
+	DefaultIcon struct {
+		IconType string `json:"iconType"`
+	} `json:"defaultIcon"`
+	DefaultText struct {
+		SimpleText    string `json:"simpleText"`
+		Accessibility struct {
+			AccessibilityData struct {
+				Label string `json:"label"`
+			} `json:"accessibilityData"`
+		} `json:"accessibility"`
+	} `json:"defaultText"`
+}
+
+type VideoPrimaryInfoRenderer struct {
+	VideoActions struct {
+		MenuRenderer struct {
+			TopLevelButtons []struct {
+				ToggleButtonRenderer               ToggleButtonRenderer `json:"toggleButtonRenderer"`
+				SegmentedLikeDislikeButtonRenderer struct {
+					LikeButton struct {
+						ToggleButtonRenderer ToggleButtonRenderer `json:"toggleButtonRenderer"`
+					} `json:"likeButton"`
+				} `json:"segmentedLikeDislikeButtonRenderer"`
+			} `json:"topLevelButtons"`
+		} `json:"menuRenderer"`
+	} `json:"videoActions"`
+	SentimentBar struct {
+		SentimentBarRenderer struct {
+			Tooltip string `json:"tooltip"`
+		} `json:"sentimentBarRenderer"`
+	} `json:"sentimentBar"`
+}
+
+type InitialData struct {
+	Contents struct {
+		TwoColumnWatchNextResults struct {
+			Results struct {
+				Results struct {
+					Contents []struct {
+						VideoPrimaryInfoRenderer *VideoPrimaryInfoRenderer `json:"videoPrimaryInfoRenderer,omitempty"`
+					} `json:"contents"`
+				} `json:"results"`
+			} `json:"results"`
+		} `json:"twoColumnWatchNextResults"`
+	} `json:"contents"`
+	PlayerOverlays struct {
+		PlayerOverlayRenderer struct {
+			DecoratedPlayerBarRenderer struct {
+				DecoratedPlayerBarRenderer struct {
+					PlayerBar struct {
+						MultiMarkersPlayerBarRenderer struct {
+							MarkersMap []struct {
+								Key   string `json:"key"`
+								Value struct {
+									Chapters []Chapter `json:"chapters"`
+								} `json:"value"`
+							} `json:"markersMap"`
+						} `json:"multiMarkersPlayerBarRenderer"`
+						ChapteredPlayerBarRenderer struct {
+							Chapters []Chapter `json:"chapters"`
+						} `json:"chapteredPlayerBarRenderer"`
+					} `json:"playerBar"`
+				} `json:"decoratedPlayerBarRenderer"`
+			} `json:"decoratedPlayerBarRenderer"`
+		} `json:"playerOverlayRenderer"`
+	} `json:"playerOverlays"`
+}
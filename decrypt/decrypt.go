@@ -9,49 +9,190 @@ import (
 	"github.com/tnychn/gotube/errors"
 )
 
+// sample is the known input used to check a compiled program before it is used.
+const sample = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghij"
+
+// OpCode identifies one of the transform functions that a player applies to an encrypted signature.
+type OpCode int
+
+const (
+	OpReverse OpCode = iota
+	OpSplice
+	OpSwap
+)
+
+func (code OpCode) String() string {
+	switch code {
+	case OpReverse:
+		return "reverse"
+	case OpSplice:
+		return "splice"
+	case OpSwap:
+		return "swap"
+	}
+	return "unknown"
+}
+
+// Operation is a single step of the transform plan found in 'base.js'.
+type Operation struct {
+	Code OpCode
+	Arg  int
+}
+
+func (op Operation) String() string {
+	return fmt.Sprintf("%v(%d)", op.Code, op.Arg)
+}
+
+// apply executes this operation on `arr` the same way as its javascript counterpart does,
+// except that swapping the elements of an empty signature is an error instead of inserting 'undefined'.
+func (op Operation) apply(arr []byte) ([]byte, error) {
+	switch op.Code {
+	case OpReverse:
+		for i, j := 0, len(arr)-1; i < j; i, j = i+1, j-1 {
+			arr[i], arr[j] = arr[j], arr[i]
+		}
+	case OpSplice:
+		// a.splice(0,b), which empties the array if b exceeds its length
+		if op.Arg > len(arr) {
+			return arr[:0], nil
+		}
+		arr = arr[op.Arg:]
+	case OpSwap:
+		// var c=a[0];a[0]=a[b%a.length];a[b%a.length]=c
+		if len(arr) == 0 {
+			return nil, fmt.Errorf("cannot swap elements of an empty signature")
+		}
+		i := op.Arg % len(arr)
+		arr[0], arr[i] = arr[i], arr[0]
+	default:
+		return nil, fmt.Errorf("unknown operation code %d", op.Code)
+	}
+	return arr, nil
+}
+
+// Decryption decrypts the signatures of the streams of the videos using the player it was created from.
 type Decryption struct {
-	transformPlan []string
-	transformMap  map[string]func([]string, int) []string
+	program []Operation
 }
 
+// NewDecryption extracts the transform plan and the transform functions from `js` (i.e. the content of 'base.js'),
+// compiles them into a program of operations, and checks the program against a sample before returning (see `check()`).
 func NewDecryption(js string) (*Decryption, error) {
 	transformPlan, err := getTransformPlan(js)
 	if err != nil {
 		return nil, err
 	}
-	v := strings.Split(transformPlan[0], ".")[0]
+	v, _, _, err := parseFunction(transformPlan[0])
+	if err != nil {
+		return nil, err
+	}
 	transformMap, err := getTransformMap(js, v)
 	if err != nil {
 		return nil, err
 	}
-	return &Decryption{transformPlan: transformPlan, transformMap: transformMap}, nil
+	program, err := compile(transformPlan, transformMap)
+	if err != nil {
+		return nil, err
+	}
+	d := &Decryption{program: program}
+	if err = d.check(); err != nil {
+		return nil, err
+	}
+	return d, nil
 }
 
+// Program returns a copy of the compiled operations of this decryption.
+func (d *Decryption) Program() []Operation {
+	return append([]Operation(nil), d.program...)
+}
+
+// DecryptSignature runs the program on the encrypted signature `s` and returns the decrypted one.
+// A step failing on `s` (e.g. removing more characters than left) is reported as an `errors.DecryptError`.
 func (d *Decryption) DecryptSignature(s string) (string, error) {
-	sig := strings.Split(s, "")
-	for _, jsFunc := range d.transformPlan {
-		funcName, args, err := d.parseFunction(jsFunc)
-		if err != nil {
-			return "", err
+	sig := []byte(s)
+	for i, op := range d.program {
+		var err error
+		if sig, err = op.apply(sig); err != nil {
+			return "", errors.DecryptError{Step: i, Operation: op.String(), Reason: err.Error()}
 		}
-		sig = d.transformMap[funcName](sig, args)
 	}
-	signature := strings.Join(sig, "")
-	return signature, nil
+	return string(sig), nil
 }
 
-func (d *Decryption) parseFunction(jsFunc string) (string, int, error) {
-	re := regexp.MustCompile(`\w+\.(\w+)\(\w,(\d+)\)`)
+// check runs the program on `sample` and compares the output with the one modelled from the plan as a permutation,
+// which catches a plan that does not run (e.g. it is empty or removes too many characters) and a wrong `apply`.
+// As both sides come from the same program, it cannot catch a transform function mapped to the wrong operation:
+// this is what the fixtures (see package `fixture`) check against signatures decrypted by the players themselves.
+func (d *Decryption) check() error {
+	if len(d.program) == 0 {
+		return errors.DecryptError{Step: -1, Reason: "empty transform plan"}
+	}
+	// the expected output is computed from the plan independently of `apply`
+	expected := make([]int, len(sample))
+	for i := range expected {
+		expected[i] = i
+	}
+	for _, op := range d.program {
+		switch op.Code {
+		case OpReverse:
+			reversed := make([]int, len(expected))
+			for i, x := range expected {
+				reversed[len(expected)-1-i] = x
+			}
+			expected = reversed
+		case OpSplice:
+			if op.Arg <= len(expected) {
+				expected = expected[op.Arg:]
+			}
+		case OpSwap:
+			if len(expected) > 0 {
+				i := op.Arg % len(expected)
+				expected[0], expected[i] = expected[i], expected[0]
+			}
+		}
+	}
+	output, err := d.DecryptSignature(sample)
+	if err != nil {
+		return err
+	}
+	want := make([]byte, len(expected))
+	for i, x := range expected {
+		want[i] = sample[x]
+	}
+	if output != string(want) {
+		return errors.DecryptError{Step: -1, Reason: fmt.Sprintf("self-check failed: got '%v', expected '%v'", output, string(want))}
+	}
+	return nil
+}
+
+// parseFunction parses a step of the transform plan (e.g. 'Xy.ab(a,3)') into its object name, function name and argument.
+func parseFunction(jsFunc string) (string, string, int, error) {
+	re := regexp.MustCompile(`^\s*([\w$]+)\.([\w$]+)\(\w,(\d+)\)\s*$`)
 	parseMatch := re.FindStringSubmatch(jsFunc)
 	if len(parseMatch) == 0 {
-		return "", 0, errors.ExtractError{Caller: "parse function", Pattern: re.String()}
+		return "", "", 0, errors.ExtractError{Caller: "parse function", Pattern: re.String()}
 	}
-	funcName := parseMatch[1]
-	funcArg, err := strconv.Atoi(parseMatch[2])
+	funcArg, err := strconv.Atoi(parseMatch[3])
 	if err != nil {
-		return "", 0, err
+		return "", "", 0, err
 	}
-	return funcName, funcArg, nil
+	return parseMatch[1], parseMatch[2], funcArg, nil
+}
+
+func compile(transformPlan []string, transformMap map[string]OpCode) ([]Operation, error) {
+	program := make([]Operation, 0, len(transformPlan))
+	for i, jsFunc := range transformPlan {
+		_, funcName, arg, err := parseFunction(jsFunc)
+		if err != nil {
+			return nil, err
+		}
+		code, ok := transformMap[funcName]
+		if !ok {
+			return nil, errors.DecryptError{Step: i, Operation: jsFunc, Reason: fmt.Sprintf("unknown transform function '%v'", funcName)}
+		}
+		program = append(program, Operation{Code: code, Arg: arg})
+	}
+	return program, nil
 }
 
 func getTransformPlan(js string) ([]string, error) {
@@ -61,7 +202,7 @@ func getTransformPlan(js string) ([]string, error) {
 			`\b[a-zA-Z0-9]+\s*&&\s*[a-zA-Z0-9]+\.set\([^,]+\s*,\s*encodeURIComponent\s*\(\s*(?P<sig>[a-zA-Z0-9$]+)\(`,
 			`\b(?P<sig>[a-zA-Z0-9$]{2})\s*=\s*function\(\s*a\s*\)\s*{\s*a\s*=\s*a\.split\(\s*""\s*\)`,
 			`(?P<sig>[a-zA-Z0-9$]+)\s*=\s*function\(\s*a\s*\)\s*{\s*a\s*=\s*a\.split\(\s*""\s*\)`,
-			`(["\'])signature\1\s*,\s*(?P<sig>[a-zA-Z0-9$]+)\(`,
+			`["']signature["']\s*,\s*(?P<sig>[a-zA-Z0-9$]+)\(`,
 			`\.sig\|\|(?P<sig>[a-zA-Z0-9$]+)\(`,
 			`yt\.akamaized\.net/\)\s*\|\|\s*.*?\s*[cs]\s*&&\s*[adf]\.set\([^,]+\s*,\s*(?:encodeURIComponent\s*\()?\s*(?P<sig>[a-zA-Z0-9$]+)\(`,
 			`b[cs]\s*&&\s*[adf]\.set\([^,]+\s*,\s*(?P<sig>[a-zA-Z0-9$]+)\(`,
@@ -91,7 +232,7 @@ func getTransformPlan(js string) ([]string, error) {
 	return strings.Split(matches[1], ";"), nil
 }
 
-func getTransformMap(js, v string) (map[string]func([]string, int) []string, error) {
+func getTransformMap(js, v string) (map[string]OpCode, error) {
 	getTransformObject := func(js, v string) ([]string, error) {
 		pattern := fmt.Sprintf(`(?s)var %v={(.*?)};`, regexp.QuoteMeta(v))
 		re := regexp.MustCompile(pattern)
@@ -106,46 +247,35 @@ func getTransformMap(js, v string) (map[string]func([]string, int) []string, err
 	if err != nil {
 		return nil, err
 	}
-	mapper := make(map[string]func([]string, int) []string)
+	mapper := make(map[string]OpCode)
 	for _, obj := range transformObject {
 		splitted := strings.SplitN(obj, ":", 2)
-		fn, err := mapFunctions(splitted[1])
+		if len(splitted) != 2 {
+			return nil, errors.ExtractError{Caller: "transform object", Pattern: "<name>:<function>"}
+		}
+		code, err := mapFunctions(splitted[1])
 		if err != nil {
 			return nil, err
 		}
-		mapper[splitted[0]] = fn
+		mapper[strings.TrimSpace(splitted[0])] = code
 	}
 	return mapper, nil
 }
 
-func mapFunctions(jsFunc string) (func([]string, int) []string, error) {
-	reverse := func(arr []string, _ int) []string {
-		for i, j := 0, len(arr)-1; i < j; i, j = i+1, j-1 {
-			arr[i], arr[j] = arr[j], arr[i]
-		}
-		return arr
-	}
-	splice := func(arr []string, b int) []string {
-		return append(arr[:b], arr[b*2:]...)
-	}
-	swap := func(arr []string, b int) []string {
-		c := arr[0]
-		arr[0] = arr[b%len(arr)]
-		arr[b] = c
-		return arr
-	}
-	var lastPattern string
-	mapper := map[string]func([]string, int) []string{
-		`{\w\.reverse\(\)}`:    reverse,
-		`{\w\.splice\(0,\w\)}`: splice,
-		`{var\s\w=\w\[0\];\w\[0\]=\w\[\w\%\w.length\];\w\[\w\]=\w}`:            swap,
-		`{var\s\w=\w\[0\];\w\[0\]=\w\[\w\%\w.length\];\w\[\w\%\w.length\]=\w}`: swap,
-	}
-	for pattern, fn := range mapper {
-		if matched, _ := regexp.MatchString(pattern, jsFunc); matched {
-			return fn, nil
+func mapFunctions(jsFunc string) (OpCode, error) {
+	patterns := []struct {
+		pattern string
+		code    OpCode
+	}{
+		{`{\w\.reverse\(\)}`, OpReverse},
+		{`{\w\.splice\(0,\w\)}`, OpSplice},
+		{`{var\s\w=\w\[0\];\w\[0\]=\w\[\w\%\w.length\];\w\[\w\]=\w}`, OpSwap},
+		{`{var\s\w=\w\[0\];\w\[0\]=\w\[\w\%\w.length\];\w\[\w\%\w.length\]=\w}`, OpSwap},
+	}
+	for _, p := range patterns {
+		if matched, _ := regexp.MatchString(p.pattern, jsFunc); matched {
+			return p.code, nil
 		}
-		lastPattern = pattern
 	}
-	return nil, errors.ExtractError{Caller: "map functions", Pattern: lastPattern}
+	return 0, errors.ExtractError{Caller: "map functions", Pattern: "<transform function patterns>"}
 }
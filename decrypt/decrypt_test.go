@@ -0,0 +1,93 @@
+package decrypt
+
+import "testing"
+
+func TestMapFunctions(t *testing.T) {
+	tests := []struct {
+		js   string
+		code OpCode
+	}{
+		{`function(a){a.reverse()}`, OpReverse},
+		{`function(a,b){a.splice(0,b)}`, OpSplice},
+		{`function(a,b){var c=a[0];a[0]=a[b%a.length];a[b]=c}`, OpSwap},
+		{`function(a,b){var c=a[0];a[0]=a[b%a.length];a[b%a.length]=c}`, OpSwap},
+	}
+	for _, test := range tests {
+		code, err := mapFunctions(test.js)
+		if err != nil {
+			t.Errorf("mapFunctions(%q): %v", test.js, err)
+		} else if code != test.code {
+			t.Errorf("mapFunctions(%q) = %v, want %v", test.js, code, test.code)
+		}
+	}
+	if _, err := mapFunctions(`function(a,b){a.push(b)}`); err == nil {
+		t.Error("mapFunctions(push): expected an error")
+	}
+}
+
+func TestDecryptSignature(t *testing.T) {
+	// the output is the one of running the player below with node
+	js := `var Qa={ab:function(a){a.reverse()},
+cd:function(a,b){var c=a[0];a[0]=a[b%a.length];a[b%a.length]=c},
+ef:function(a,b){a.splice(0,b)}};
+var Xy=function(a){a=a.split("");Qa.ef(a,2);Qa.cd(a,9);Qa.ab(a,1);Qa.cd(a,40);return a.join("")};
+g.Yq=function(a,b,c){c&&d.set(b,encodeURIComponent(Xy(decodeURIComponent(c))))};`
+	d, err := NewDecryption(js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Operation{{OpSplice, 2}, {OpSwap, 9}, {OpReverse, 1}, {OpSwap, 40}}
+	program := d.Program()
+	if len(program) != len(want) {
+		t.Fatalf("got program %v, want %v", program, want)
+	}
+	for i := range want {
+		if program[i] != want[i] {
+			t.Errorf("step %d = %v, want %v", i, program[i], want[i])
+		}
+	}
+	output, err := d.DecryptSignature("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "X-9876543210zyxwvutsrqponmlkjihgfedcbaZY_WVUTSRQPONMCKJIHGFEDL"; output != expected {
+		t.Errorf("DecryptSignature() = %q, want %q", output, expected)
+	}
+	if _, err = d.DecryptSignature("a"); err == nil {
+		t.Error("DecryptSignature(a): expected an error")
+	}
+}
+
+func TestNewDecryptionInvalid(t *testing.T) {
+	for _, js := range []string{"", "var truncated={", `c.set("signature",Xy(`} {
+		if _, err := NewDecryption(js); err == nil {
+			t.Errorf("NewDecryption(%q): expected an error", js)
+		}
+	}
+}
+
+func TestOperationApply(t *testing.T) {
+	// the expected results are those of the javascript counterparts run by node
+	tests := []struct {
+		op   Operation
+		in   string
+		want string
+	}{
+		{Operation{OpSplice, 3}, "abcdef", "def"},
+		{Operation{OpSplice, 6}, "abcdef", ""},
+		{Operation{OpSplice, 9}, "abcdef", ""},
+		{Operation{OpSwap, 8}, "abcdef", "cbadef"},
+		{Operation{OpReverse, 0}, "abcdef", "fedcba"},
+	}
+	for _, test := range tests {
+		got, err := test.op.apply([]byte(test.in))
+		if err != nil {
+			t.Errorf("%v on %q: %v", test.op, test.in, err)
+		} else if string(got) != test.want {
+			t.Errorf("%v on %q = %q, want %q", test.op, test.in, got, test.want)
+		}
+	}
+	if _, err := (Operation{OpSwap, 1}).apply(nil); err == nil {
+		t.Error("swap on an empty signature: expected an error")
+	}
+}
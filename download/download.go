@@ -9,7 +9,6 @@ import (
 	"strconv"
 	"sync"
 
-	"github.com/tnychn/gotube/errors"
 	"github.com/tnychn/gotube/utils"
 )
 
@@ -62,6 +61,12 @@ func Download(dlurl string, destdir, filename, ext string, overwrite bool, onSta
 	chunk := total / workerNum
 
 	var errs []error
+	var errsLock sync.Mutex
+	addErr := func(err error) {
+		errsLock.Lock()
+		errs = append(errs, err)
+		errsLock.Unlock()
+	}
 	var paths []string
 	writer := &Writer{onProgress: onProgress}
 	group := new(sync.WaitGroup)
@@ -77,27 +82,29 @@ func Download(dlurl string, destdir, filename, ext string, overwrite bool, onSta
 		group.Add(1)
 		go func(i, min, max int64, path string) {
 			defer group.Done()
-			h := make(http.Header)
-			h.Add("Range", fmt.Sprintf("bytes=%d-%d", min, max))
-			resp, err := utils.HttpRequest(http.MethodGet, dlurl, &h)
-			if err != nil {
-				errs = append(errs, err)
-				return
-			}
-			if resp.StatusCode >= 300 {
-				errs = append(errs, errors.HttpError{StatusCode: resp.StatusCode})
-				return
-			}
-			defer resp.Body.Close()
-
 			file, err := os.Create(path)
 			if err != nil {
-				errs = append(errs, err)
+				addErr(err)
 				return
 			}
 			defer file.Close()
-			if _, err = io.Copy(file, io.TeeReader(resp.Body, writer)); err != nil {
-				return
+			// a retried request resumes from the bytes already written by the previous attempts
+			var written int64
+			headers := func() *http.Header {
+				h := make(http.Header)
+				h.Add("Range", fmt.Sprintf("bytes=%d-%d", min+written, max))
+				return &h
+			}
+			err = utils.HttpRead(dlurl, headers, func(resp *http.Response) error {
+				if written > 0 && resp.StatusCode != http.StatusPartialContent {
+					return fmt.Errorf("range request resumed at byte %d returned status %d", min+written, resp.StatusCode)
+				}
+				n, err := io.Copy(file, io.TeeReader(resp.Body, writer))
+				written += n
+				return err
+			})
+			if err != nil {
+				addErr(err)
 			}
 		}(i, min, max, path)
 	}
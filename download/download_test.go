@@ -0,0 +1,107 @@
+package download
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tnychn/gotube/errors"
+	"github.com/tnychn/gotube/utils"
+)
+
+func TestDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 1000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "video.mp4", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+	var total, written int64
+	path, err := Download(server.URL, t.TempDir(), "video", "mp4", false,
+		func(t int64) { total = t }, func(w int64) { written = w })
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("downloaded %d bytes which differ from the %d bytes served", len(got), len(content))
+	}
+	if total != int64(len(content)) || written != total {
+		t.Errorf("got total %d and written %d, want %d", total, written, len(content))
+	}
+}
+
+func TestDownloadResume(t *testing.T) {
+	previous := utils.Retry
+	utils.Retry = utils.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+	defer func() { utils.Retry = previous }()
+	content := bytes.Repeat([]byte("0123456789abcdef"), 1000)
+	var lock sync.Mutex
+	var ranges []string
+	cut := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		first := !cut && r.Header.Get("Range") == "bytes=1600-3199"
+		cut = cut || first
+		lock.Unlock()
+		if first {
+			// the connection is closed after the first 100 bytes of the range
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 1600-3199/%d", len(content)))
+			w.Header().Set("Content-Length", "1600")
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write(content[1600:1700])
+			return
+		}
+		http.ServeContent(w, r, "video.mp4", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+	var written int64
+	path, err := Download(server.URL, t.TempDir(), "video", "mp4", false, nil, func(w int64) { written = w })
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) || written != int64(len(content)) {
+		t.Errorf("downloaded %d bytes (%d reported) which differ from the %d bytes served", len(got), written, len(content))
+	}
+	resumed := false
+	for _, r := range ranges {
+		resumed = resumed || r == "bytes=1700-3199"
+	}
+	if !resumed {
+		t.Errorf("the cut range was not resumed, got ranges %v", ranges)
+	}
+}
+
+func TestDownloadErrors(t *testing.T) {
+	previous := utils.Retry
+	utils.Retry = utils.NoRetryPolicy
+	defer func() { utils.Retry = previous }()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", "1000")
+			return
+		}
+		// every chunk fails at once, which must not race
+		if strings.HasPrefix(r.Header.Get("Range"), "bytes=") {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+	_, err := Download(server.URL, t.TempDir(), "video", "mp4", false, nil, nil)
+	if !errors.Is(err, errors.ErrHttp) {
+		t.Errorf("got %#v, want a HttpError", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 type HttpError struct {
 	StatusCode int
+	URL        string
 }
 
 func (err HttpError) Name() string {
@@ -17,6 +18,10 @@ func (err HttpError) Error() string {
 	return fmt.Sprintf("http request failed with %d %v status code", err.StatusCode, http.StatusText(err.StatusCode))
 }
 
+func (err HttpError) Is(target error) bool {
+	return target == ErrHttp
+}
+
 type ExtractError struct {
 	Caller  string
 	Pattern string
@@ -30,8 +35,34 @@ func (err ExtractError) Error() string {
 	return fmt.Sprintf("%v: could not find match for pattern '%v'", err.Caller, err.Pattern)
 }
 
+func (err ExtractError) Is(target error) bool {
+	return target == ErrExtract
+}
+
+type DecryptError struct {
+	Step      int
+	Operation string
+	Reason    string
+}
+
+func (err DecryptError) Name() string {
+	return "decrypt"
+}
+
+func (err DecryptError) Error() string {
+	if err.Step < 0 {
+		return fmt.Sprintf("decryption failed: %v", err.Reason)
+	}
+	return fmt.Sprintf("decryption failed at step %d (%v): %v", err.Step, err.Operation, err.Reason)
+}
+
+func (err DecryptError) Is(target error) bool {
+	return target == ErrDecrypt
+}
+
 type VideoUnavailableError struct {
 	VideoID string
+	Reason  string
 }
 
 func (err VideoUnavailableError) Name() string {
@@ -39,7 +70,13 @@ func (err VideoUnavailableError) Name() string {
 }
 
 func (err VideoUnavailableError) Error() string {
-	return fmt.Sprintf("video %v is unavailable", err.VideoID)
+	return unavailable(err.VideoID, "is unavailable", err.Reason)
+}
+
+// Is reports whether `target` is `ErrUnavailable` or a `VideoUnavailableError`, regardless of its fields.
+func (err VideoUnavailableError) Is(target error) bool {
+	_, is := target.(VideoUnavailableError)
+	return is || target == ErrUnavailable
 }
 
 type VideoUnsupportedError struct {
@@ -54,6 +91,10 @@ func (err VideoUnsupportedError) Error() string {
 	return fmt.Sprintf("video %v is unsupported (live content)", err.VideoID)
 }
 
+func (err VideoUnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
 type RequestFailedError struct {
 	Reason string
 }
@@ -65,3 +106,7 @@ func (err RequestFailedError) Name() string {
 func (err RequestFailedError) Error() string {
 	return fmt.Sprintf("youtube request failed due to '%v'", err.Reason)
 }
+
+func (err RequestFailedError) Is(target error) bool {
+	return target == ErrRequestFailed
+}
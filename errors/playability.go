@@ -0,0 +1,169 @@
+package errors
+
+import "fmt"
+
+// The errors below are returned when YouTube refuses to play a video.
+// Each of them carries the original reason text given by YouTube,
+// matches its own type in `errors.Is` regardless of its fields,
+// and unwraps to a `VideoUnavailableError` so that they can all be handled at once.
+
+func unavailable(videoID, what, reason string) string {
+	if reason == "" {
+		return fmt.Sprintf("video %v %v", videoID, what)
+	}
+	return fmt.Sprintf("video %v %v: %v", videoID, what, reason)
+}
+
+type VideoPrivateError struct {
+	VideoID string
+	Reason  string
+}
+
+func (err VideoPrivateError) Name() string {
+	return "private"
+}
+
+func (err VideoPrivateError) Error() string {
+	return unavailable(err.VideoID, "is private", err.Reason)
+}
+
+func (err VideoPrivateError) Is(target error) bool {
+	_, is := target.(VideoPrivateError)
+	return is
+}
+
+func (err VideoPrivateError) Unwrap() error {
+	return VideoUnavailableError{VideoID: err.VideoID, Reason: err.Reason}
+}
+
+type VideoRemovedError struct {
+	VideoID string
+	Reason  string
+}
+
+func (err VideoRemovedError) Name() string {
+	return "removed"
+}
+
+func (err VideoRemovedError) Error() string {
+	return unavailable(err.VideoID, "has been removed", err.Reason)
+}
+
+func (err VideoRemovedError) Is(target error) bool {
+	_, is := target.(VideoRemovedError)
+	return is
+}
+
+func (err VideoRemovedError) Unwrap() error {
+	return VideoUnavailableError{VideoID: err.VideoID, Reason: err.Reason}
+}
+
+type VideoRegionBlockedError struct {
+	VideoID string
+	Reason  string
+}
+
+func (err VideoRegionBlockedError) Name() string {
+	return "region"
+}
+
+func (err VideoRegionBlockedError) Error() string {
+	return unavailable(err.VideoID, "is blocked in this region", err.Reason)
+}
+
+func (err VideoRegionBlockedError) Is(target error) bool {
+	_, is := target.(VideoRegionBlockedError)
+	return is
+}
+
+func (err VideoRegionBlockedError) Unwrap() error {
+	return VideoUnavailableError{VideoID: err.VideoID, Reason: err.Reason}
+}
+
+type VideoMembersOnlyError struct {
+	VideoID string
+	Reason  string
+}
+
+func (err VideoMembersOnlyError) Name() string {
+	return "members"
+}
+
+func (err VideoMembersOnlyError) Error() string {
+	return unavailable(err.VideoID, "is available to channel members only", err.Reason)
+}
+
+func (err VideoMembersOnlyError) Is(target error) bool {
+	_, is := target.(VideoMembersOnlyError)
+	return is
+}
+
+func (err VideoMembersOnlyError) Unwrap() error {
+	return VideoUnavailableError{VideoID: err.VideoID, Reason: err.Reason}
+}
+
+type VideoAgeRestrictedError struct {
+	VideoID string
+	Reason  string
+}
+
+func (err VideoAgeRestrictedError) Name() string {
+	return "age"
+}
+
+func (err VideoAgeRestrictedError) Error() string {
+	return unavailable(err.VideoID, "is age-restricted", err.Reason)
+}
+
+func (err VideoAgeRestrictedError) Is(target error) bool {
+	_, is := target.(VideoAgeRestrictedError)
+	return is
+}
+
+func (err VideoAgeRestrictedError) Unwrap() error {
+	return VideoUnavailableError{VideoID: err.VideoID, Reason: err.Reason}
+}
+
+type VideoCopyrightError struct {
+	VideoID string
+	Reason  string
+}
+
+func (err VideoCopyrightError) Name() string {
+	return "copyright"
+}
+
+func (err VideoCopyrightError) Error() string {
+	return unavailable(err.VideoID, "is blocked due to a copyright claim", err.Reason)
+}
+
+func (err VideoCopyrightError) Is(target error) bool {
+	_, is := target.(VideoCopyrightError)
+	return is
+}
+
+func (err VideoCopyrightError) Unwrap() error {
+	return VideoUnavailableError{VideoID: err.VideoID, Reason: err.Reason}
+}
+
+type LoginRequiredError struct {
+	VideoID string
+	Reason  string
+}
+
+func (err LoginRequiredError) Name() string {
+	return "login"
+}
+
+func (err LoginRequiredError) Error() string {
+	return unavailable(err.VideoID, "requires signing in", err.Reason)
+}
+
+func (err LoginRequiredError) Is(target error) bool {
+	_, is := target.(LoginRequiredError)
+	return is
+}
+
+func (err LoginRequiredError) Unwrap() error {
+	return VideoUnavailableError{VideoID: err.VideoID, Reason: err.Reason}
+}
@@ -0,0 +1,105 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+)
+
+// Sentinel errors which can be matched by `errors.Is` against the corresponding error types of this package.
+var (
+	ErrHttp          = stderrors.New("http request failed")
+	ErrExtract       = stderrors.New("extraction failed")
+	ErrDecrypt       = stderrors.New("decryption failed")
+	ErrUnavailable   = stderrors.New("video unavailable")
+	ErrUnsupported   = stderrors.New("video unsupported")
+	ErrRequestFailed = stderrors.New("youtube request failed")
+)
+
+// Is is a shorthand of the standard `errors.Is`.
+func Is(err, target error) bool { return stderrors.Is(err, target) }
+
+// As is a shorthand of the standard `errors.As`.
+func As(err error, target interface{}) bool { return stderrors.As(err, target) }
+
+// OpError wraps an error with the context of the operation during which it occurred.
+type OpError struct {
+	Op      string
+	URL     string
+	VideoID string
+	Itag    int
+	Attempt int
+	Err     error
+}
+
+// Name returns the name of the wrapped error if it has one, the name of the operation otherwise.
+func (err OpError) Name() string {
+	if named, is := err.Err.(interface{ Name() string }); is {
+		return named.Name()
+	}
+	return err.Op
+}
+
+func (err OpError) Error() string {
+	var context []string
+	if err.VideoID != "" {
+		context = append(context, "video "+err.VideoID)
+	}
+	if err.Itag != 0 {
+		context = append(context, fmt.Sprintf("itag %d", err.Itag))
+	}
+	if err.Attempt > 1 {
+		context = append(context, fmt.Sprintf("%d attempts", err.Attempt))
+	}
+	s := err.Op
+	if len(context) > 0 {
+		s += " (" + strings.Join(context, ", ") + ")"
+	}
+	return fmt.Sprintf("%v: %v", s, err.Err)
+}
+
+func (err OpError) Unwrap() error {
+	return err.Err
+}
+
+// Retryable reports whether `err` is likely to be transient, such that the failed request may succeed if tried again.
+// These are the timeouts (including those of `http.Client.Timeout`), the connections reset or refused,
+// the responses cut short and the http errors that are retryable (see `HttpError.Retryable()`).
+// Any other error (e.g. a cancelled or expired context of the caller, or an invalid certificate)
+// would fail the same way again.
+func Retryable(err error) bool {
+	if err == nil || Is(err, context.Canceled) || isContextDeadline(err) {
+		return false
+	}
+	var httpErr HttpError
+	if As(err, &httpErr) {
+		return httpErr.Retryable()
+	}
+	if Is(err, io.ErrUnexpectedEOF) || Is(err, syscall.ECONNRESET) || Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var netErr net.Error
+	return As(err, &netErr) && netErr.Timeout()
+}
+
+// isContextDeadline reports whether `err` wraps the `context.DeadlineExceeded` of an expired context.
+// Unlike `Is`, it does not match the timeout error of `http.Client`, which only claims to be `context.DeadlineExceeded`.
+func isContextDeadline(err error) bool {
+	for ; err != nil; err = stderrors.Unwrap(err) {
+		if err == context.DeadlineExceeded {
+			return true
+		}
+	}
+	return false
+}
+
+// Retryable reports whether the status code of this error indicates a transient failure,
+// i.e. it is 429 (too many requests) or 5xx (server error).
+func (err HttpError) Retryable() bool {
+	return err.StatusCode == http.StatusTooManyRequests || err.StatusCode >= 500 && err.StatusCode < 600
+}
@@ -0,0 +1,82 @@
+package errors
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// slowGet returns the error of requesting a server which does not respond in time,
+// either because of the timeout of the client or the deadline of the request context.
+func slowGet(t *testing.T, clientTimeout bool) error {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+	client := &http.Client{}
+	ctx := context.Background()
+	if clientTimeout {
+		client.Timeout = time.Millisecond * 50
+	} else {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Millisecond*50)
+		defer cancel()
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := client.Do(request)
+	if err == nil {
+		response.Body.Close()
+		t.Fatal("expected the request to time out")
+	}
+	return err
+}
+
+func TestRetryable(t *testing.T) {
+	get := func(err error) error {
+		return OpError{Op: "fetch", URL: "https://youtube.com", Err: &url.Error{Op: "Get", URL: "https://youtube.com", Err: err}}
+	}
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{HttpError{StatusCode: 429}, true},
+		{HttpError{StatusCode: 500}, true},
+		{OpError{Op: "fetch", Err: HttpError{StatusCode: 503}}, true},
+		{HttpError{StatusCode: 403}, false},
+		{HttpError{StatusCode: 404}, false},
+		{OpError{Op: "fetch", Err: slowGet(t, true)}, true},
+		{OpError{Op: "fetch", Err: slowGet(t, false)}, false},
+		{get(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), true},
+		{get(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), true},
+		{fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), true},
+		{get(context.Canceled), false},
+		{get(context.DeadlineExceeded), false},
+		{get(x509.UnknownAuthorityError{}), false},
+		{get(x509.HostnameError{Host: "youtube.com", Certificate: &x509.Certificate{}}), false},
+		{get(&net.DNSError{Err: "no such host", Name: "youtube.invalid", IsNotFound: true}), false},
+		{ExtractError{Caller: "player js url"}, false},
+	}
+	for _, test := range tests {
+		if got := Retryable(test.err); got != test.want {
+			t.Errorf("Retryable(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
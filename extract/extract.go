@@ -1,6 +1,7 @@
 package extract
 
 import (
+	"encoding/json"
 	"net/url"
 	"regexp"
 	"strings"
@@ -72,3 +73,49 @@ func PlayerConfig(watchHTML string) (string, error) {
 	}
 	return "", errors.ExtractError{Caller: "player config", Pattern: "<player config patterns>"}
 }
+
+func PlayerJSURL(html string) (string, error) {
+	config, err := PlayerConfig(html)
+	if err != nil {
+		return "", err
+	}
+	playerConfig := struct {
+		Assets struct {
+			CSS string `json:"css"`
+			JS  string `json:"js"`
+		} `json:"assets"`
+	}{}
+	if err = json.Unmarshal([]byte(config), &playerConfig); err != nil {
+		return "", err
+	}
+	if playerConfig.Assets.JS == "" {
+		return "", errors.ExtractError{Caller: "player js url", Pattern: "assets.js"}
+	}
+	return "https://youtube.com" + playerConfig.Assets.JS, nil
+}
+
+// RegionsAllowed returns the ISO 3166 codes of the countries listed by the 'regionsAllowed' meta tag of the watch page,
+// or nil if there is no such tag.
+func RegionsAllowed(watchHTML string) []string {
+	matches := regexp.MustCompile(`<meta\s+itemprop="regionsAllowed"\s+content="([A-Z,]*)"`).FindStringSubmatch(watchHTML)
+	if len(matches) == 0 || matches[1] == "" {
+		return nil
+	}
+	return strings.Split(matches[1], ",")
+}
+
+func InitialData(watchHTML string) (string, error) {
+	patterns := []string{
+		`window\["ytInitialData"\]\s*=\s*({.+?});\s*window\["ytInitialPlayerResponse"\]`,
+		`(?:window\["ytInitialData"\]|var ytInitialData)\s*=\s*({.+?});\s*</script>`,
+		`window\["ytInitialData"\]\s*=\s*({.+?});\n`,
+	}
+	for _, pattern := range patterns {
+		matches := regexp.MustCompile(pattern).FindStringSubmatch(watchHTML)
+		if len(matches) == 0 {
+			continue
+		}
+		return matches[1], nil
+	}
+	return "", errors.ExtractError{Caller: "initial data", Pattern: "<initial data patterns>"}
+}
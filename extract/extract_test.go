@@ -0,0 +1,22 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegionsAllowed(t *testing.T) {
+	tests := []struct {
+		html string
+		want []string
+	}{
+		{`<meta itemprop="isFamilyFriendly" content="true"><meta itemprop="regionsAllowed" content="AD,AE,US"><meta itemprop="interactionCount" content="1">`, []string{"AD", "AE", "US"}},
+		{`<meta itemprop="regionsAllowed" content="">`, nil},
+		{`<meta itemprop="isFamilyFriendly" content="true">`, nil},
+	}
+	for _, test := range tests {
+		if got := RegionsAllowed(test.html); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("RegionsAllowed(%q) = %v, want %v", test.html, got, test.want)
+		}
+	}
+}
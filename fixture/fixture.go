@@ -0,0 +1,191 @@
+package fixture
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/tnychn/gotube/decrypt"
+	"github.com/tnychn/gotube/extract"
+	"github.com/tnychn/gotube/utils"
+)
+
+const (
+	jsFile       = "base.js"
+	htmlFile     = "watch.html"
+	expectedFile = "expected.json"
+)
+
+// Signature is a pair of an encrypted signature and its expected decrypted output.
+type Signature struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// Expected carries the expected outputs of a fixture.
+type Expected struct {
+	PlayerURL  string      `json:"player_url"`
+	Signatures []Signature `json:"signatures"`
+}
+
+// Fixture represents a saved snapshot of a player ('base.js') and the watch page it was found in,
+// together with the outputs that the extraction and decryption are expected to produce.
+type Fixture struct {
+	Name string
+	Dir  string
+
+	JS        string
+	WatchHTML string
+	Expected  Expected
+}
+
+// Load loads the fixture stored in `dir`.
+// `base.js` and `expected.json` are required, `watch.html` is optional.
+func Load(dir string) (*Fixture, error) {
+	fixture := &Fixture{Name: filepath.Base(dir), Dir: dir}
+	js, err := ioutil.ReadFile(filepath.Join(dir, jsFile))
+	if err != nil {
+		return nil, err
+	}
+	fixture.JS = string(js)
+	html, err := ioutil.ReadFile(filepath.Join(dir, htmlFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	fixture.WatchHTML = string(html)
+	expected, err := ioutil.ReadFile(filepath.Join(dir, expectedFile))
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(expected, &fixture.Expected); err != nil {
+		return nil, fmt.Errorf("%v: %v", fixture.Name, err)
+	}
+	return fixture, nil
+}
+
+// LoadAll loads every fixture found in the subdirectories of `root`, sorted by name.
+func LoadAll(root string) (fixtures []*Fixture, err error) {
+	infos, err := ioutil.ReadDir(root)
+	if err != nil {
+		return
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name() < infos[j].Name()
+	})
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		fixture, err := Load(filepath.Join(root, info.Name()))
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	return
+}
+
+// Verify runs the extraction and decryption against this fixture,
+// and returns an error describing the first output that does not match the expected one.
+func (fixture *Fixture) Verify() error {
+	if fixture.WatchHTML != "" {
+		jsURL, err := extract.PlayerJSURL(fixture.WatchHTML)
+		if err != nil {
+			return fmt.Errorf("%v: %v", fixture.Name, err)
+		}
+		if jsURL != fixture.Expected.PlayerURL {
+			return fmt.Errorf("%v: player url mismatch: got '%v', expected '%v'", fixture.Name, jsURL, fixture.Expected.PlayerURL)
+		}
+	}
+	decryption, err := decrypt.NewDecryption(fixture.JS)
+	if err != nil {
+		return fmt.Errorf("%v: %v", fixture.Name, err)
+	}
+	for _, signature := range fixture.Expected.Signatures {
+		output, err := decryption.DecryptSignature(signature.Input)
+		if err != nil {
+			return fmt.Errorf("%v: %v", fixture.Name, err)
+		}
+		if output != signature.Output {
+			return fmt.Errorf("%v: signature mismatch for '%v': got '%v', expected '%v'", fixture.Name, signature.Input, output, signature.Output)
+		}
+	}
+	return nil
+}
+
+// Save writes this fixture into its directory, creating the directory if necessary.
+func (fixture *Fixture) Save() (err error) {
+	if err = os.MkdirAll(fixture.Dir, os.ModePerm); err != nil {
+		return
+	}
+	if err = ioutil.WriteFile(filepath.Join(fixture.Dir, jsFile), []byte(fixture.JS), 0644); err != nil {
+		return
+	}
+	if fixture.WatchHTML != "" {
+		if err = ioutil.WriteFile(filepath.Join(fixture.Dir, htmlFile), []byte(fixture.WatchHTML), 0644); err != nil {
+			return
+		}
+	}
+	expected, err := json.MarshalIndent(fixture.Expected, "", "  ")
+	if err != nil {
+		return
+	}
+	return ioutil.WriteFile(filepath.Join(fixture.Dir, expectedFile), append(expected, '\n'), 0644)
+}
+
+// Capture fetches the page at `pageURL` (watch page or embed page) and the player it refers to,
+// decrypts the given encrypted `signatures` with the current implementation,
+// then saves everything as a new fixture under `root`.
+// As the expected outputs are recorded from the current implementation, they only catch regressions:
+// check them against the signatures decrypted by the player itself before committing the fixture.
+// If `name` is empty, it defaults to the version of the player.
+func Capture(root, name, pageURL string, signatures []string) (*Fixture, error) {
+	content, err := utils.HttpFetch(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	html := string(content)
+	jsURL, err := extract.PlayerJSURL(html)
+	if err != nil {
+		return nil, err
+	}
+	if content, err = utils.HttpFetch(jsURL); err != nil {
+		return nil, err
+	}
+	js := string(content)
+
+	if name == "" {
+		name = playerVersion(jsURL)
+	}
+	fixture := &Fixture{
+		Name:      name,
+		Dir:       filepath.Join(root, name),
+		JS:        js,
+		WatchHTML: html,
+		Expected:  Expected{PlayerURL: jsURL},
+	}
+	decryption, err := decrypt.NewDecryption(js)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range signatures {
+		output, err := decryption.DecryptSignature(s)
+		if err != nil {
+			return nil, err
+		}
+		fixture.Expected.Signatures = append(fixture.Expected.Signatures, Signature{Input: s, Output: output})
+	}
+	return fixture, fixture.Save()
+}
+
+func playerVersion(jsURL string) string {
+	matches := regexp.MustCompile(`/s/player/([0-9A-Za-z_-]+)/`).FindStringSubmatch(jsURL)
+	if len(matches) == 0 {
+		return "player"
+	}
+	return matches[1]
+}
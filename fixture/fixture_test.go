@@ -0,0 +1,59 @@
+package fixture
+
+import (
+	"testing"
+
+	"github.com/tnychn/gotube/decrypt"
+)
+
+func TestVerifyAll(t *testing.T) {
+	fixtures, err := LoadAll("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures found in testdata")
+	}
+	for _, fixture := range fixtures {
+		if err := fixture.Verify(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// TestKnownSignatures checks the decryption against signatures decrypted by running the players themselves
+// (with node), since the outputs in 'expected.json' may be recorded from this implementation by `Capture()`.
+func TestKnownSignatures(t *testing.T) {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+	tests := []struct {
+		fixture string
+		known   []Signature
+	}{
+		{"synthetic-reverse-swap", []Signature{
+			{alphabet, "k-9876543210_yxwvutsrqponmlzjihgfedcbaZYXWVUTSRQPONMLKJIHGFEACBD"},
+			{"gotube", "tbgeou"},
+		}},
+		{"synthetic-splice-modswap", []Signature{
+			{alphabet, "376548210zyxwvutsrqponmlkjihgfedcbaZYXWVUTSRQPONMLCJIHGFEDK"},
+			{"gotube", "b"},
+		}},
+	}
+	for _, test := range tests {
+		fixture, err := Load("testdata/" + test.fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decryption, err := decrypt.NewDecryption(fixture.JS)
+		if err != nil {
+			t.Fatalf("%v: %v", test.fixture, err)
+		}
+		for _, signature := range append(test.known, fixture.Expected.Signatures...) {
+			output, err := decryption.DecryptSignature(signature.Input)
+			if err != nil {
+				t.Errorf("%v: %v", test.fixture, err)
+			} else if output != signature.Output {
+				t.Errorf("%v: DecryptSignature(%q) = %q, want %q", test.fixture, signature.Input, output, signature.Output)
+			}
+		}
+	}
+}
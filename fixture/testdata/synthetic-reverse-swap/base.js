@@ -0,0 +1,6 @@
+var _yt_player={};(function(g){var window=this;
+var Qa={ab:function(a){a.reverse()},
+cd:function(a,b){var c=a[0];a[0]=a[b%a.length];a[b%a.length]=c}};
+var Xy=function(a){a=a.split("");Qa.cd(a,3);Qa.ab(a,1);Qa.cd(a,12);Qa.cd(a,27);return a.join("")};
+g.Yq=function(a,b,c){c&&d.set(b,encodeURIComponent(Xy(decodeURIComponent(c))))};
+})(_yt_player);
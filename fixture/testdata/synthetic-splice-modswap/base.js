@@ -0,0 +1,7 @@
+var _yt_player={};(function(g){var window=this;
+var Zt={Kd:function(a,b){var c=a[0];a[0]=a[b%a.length];a[b%a.length]=c},
+Wm:function(a){a.reverse()},
+yE:function(a,b){a.splice(0,b)}};
+var Ro=function(a){a=a.split("");Zt.yE(a,2);Zt.Kd(a,70);Zt.Wm(a,44);Zt.yE(a,3);Zt.Kd(a,5);return a.join("")};
+g.Yq=function(a,b,c){c&&d.set(b,encodeURIComponent(Ro(decodeURIComponent(c))))};
+})(_yt_player);
@@ -0,0 +1,58 @@
+package gotube
+
+import (
+	"net/http"
+
+	"github.com/tnychn/gotube/utils"
+)
+
+// RetryPolicy describes how failed HTTP requests are retried (see `utils.RetryPolicy`).
+type RetryPolicy = utils.RetryPolicy
+
+// SetRetryPolicy sets the retry policy applied to all HTTP requests made by gotube.
+// Use `utils.NoRetryPolicy` to disable retrying.
+// It is not safe to call while requests are in flight (e.g. during a download): set it beforehand.
+func SetRetryPolicy(policy RetryPolicy) {
+	utils.Retry = policy
+}
+
+// ProxyConfig describes how the HTTP requests are sent over the network (see `utils.ProxyConfig`).
+type ProxyConfig = utils.ProxyConfig
+
+var proxyConfig *ProxyConfig
+
+// SetProxy routes all HTTP requests made by gotube according to `config`.
+// If it has multiple proxies, the next one is used for each video from its initialization (see `Video.Initialize()`),
+// so the streams of a video must be downloaded before the next video is initialized.
+func SetProxy(config *ProxyConfig) error {
+	transport, err := config.Transport()
+	if err != nil {
+		return err
+	}
+	utils.Client.Transport = transport
+	proxyConfig = config
+	return nil
+}
+
+// rotateProxy switches to the next proxy, if any (see `SetProxy()`).
+func rotateProxy() {
+	if proxyConfig != nil {
+		proxyConfig.Rotate()
+	}
+}
+
+// SetCookieJar attaches `jar` to all HTTP requests made by gotube (watch pages, player API, 'base.js', media, etc.),
+// so that videos which require signing in can be retrieved. A nil `jar` detaches the current one.
+func SetCookieJar(jar http.CookieJar) {
+	utils.Client.Jar = jar
+}
+
+// LoadCookieFile loads the cookies from a Netscape-format cookie file (i.e. 'cookies.txt') and attaches them.
+func LoadCookieFile(path string) error {
+	jar, err := utils.LoadCookies(path)
+	if err != nil {
+		return err
+	}
+	SetCookieJar(jar)
+	return nil
+}
@@ -0,0 +1,112 @@
+package gotube
+
+import (
+	"sort"
+	"strings"
+)
+
+// Preference configures how streams of the same resolution (for video) or bitrate (for audio) are ranked.
+// Each list is ordered from the most preferred to the least preferred, and unlisted values rank below listed ones.
+type Preference struct {
+	// VideoCodecs lists prefixes of video codecs (e.g. 'avc1' matches 'avc1.64001F').
+	// 'vp9' matches the long form of its codec string as well (e.g. 'vp09.02.51.10').
+	VideoCodecs []string
+	// AudioCodecs lists prefixes of audio codecs (e.g. 'mp4a' matches 'mp4a.40.2').
+	AudioCodecs []string
+	// Containers lists subtypes (e.g. 'mp4', 'webm').
+	Containers []string
+}
+
+// DefaultPreference prefers the most compatible codecs (H.264 and AAC) in mp4 containers.
+// Newer codecs (e.g. 'av01', 'vp9' and 'opus') can be preferred with `SetPreference()` instead.
+var DefaultPreference = Preference{
+	VideoCodecs: []string{"avc1", "vp9", "av01"},
+	AudioCodecs: []string{"mp4a", "opus"},
+	Containers:  []string{"mp4", "webm"},
+}
+
+var preference = DefaultPreference
+
+// SetPreference sets the preference used by `Sorted()`, `Best()` and `Worst()` of `VideoStreams` and `AudioStreams`.
+func SetPreference(p Preference) {
+	preference = p
+}
+
+// rank returns the score of `value` in `prefixes`, the higher the more preferred (0 if not listed).
+func rank(prefixes []string, value string) int {
+	if strings.HasPrefix(value, "vp09") {
+		value = "vp9" + strings.TrimPrefix(value, "vp09")
+	}
+	for i, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return len(prefixes) - i
+		}
+	}
+	return 0
+}
+
+// compare returns -1, 0 or 1 for a < b, a == b or a > b respectively, in the order of the given keys.
+func compare(keys ...[2]int64) int {
+	for _, key := range keys {
+		if key[0] < key[1] {
+			return -1
+		}
+		if key[0] > key[1] {
+			return 1
+		}
+	}
+	return 0
+}
+
+func b2i(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// lessVideo ranks video streams by resolution, then frame rate, then codec and container preference, then bitrate.
+// The itag is used as the last resort so that the order is always deterministic.
+func (p Preference) lessVideo(a, b *VideoStream) bool {
+	return compare(
+		[2]int64{int64(a.Height), int64(b.Height)},
+		[2]int64{int64(a.Width), int64(b.Width)},
+		[2]int64{int64(a.FPS), int64(b.FPS)},
+		[2]int64{int64(rank(p.VideoCodecs, a.VideoCodec)), int64(rank(p.VideoCodecs, b.VideoCodec))},
+		[2]int64{int64(rank(p.Containers, a.Subtype())), int64(rank(p.Containers, b.Subtype()))},
+		[2]int64{a.Bitrate, b.Bitrate},
+		[2]int64{a.AverageBitrate, b.AverageBitrate},
+		[2]int64{int64(a.Itag()), int64(b.Itag())},
+	) < 0
+}
+
+// lessAudio ranks audio streams of the default track above the others, then by bitrate, then codec and container preference.
+// The itag is used as the last resort so that the order is always deterministic.
+func (p Preference) lessAudio(a, b *AudioStream) bool {
+	return compare(
+		[2]int64{b2i(a.IsDefaultTrack), b2i(b.IsDefaultTrack)},
+		[2]int64{a.AverageBitrate, b.AverageBitrate},
+		[2]int64{a.Bitrate, b.Bitrate},
+		[2]int64{int64(rank(p.AudioCodecs, a.Codec)), int64(rank(p.AudioCodecs, b.Codec))},
+		[2]int64{int64(rank(p.Containers, a.Subtype())), int64(rank(p.Containers, b.Subtype()))},
+		[2]int64{int64(a.Itag()), int64(b.Itag())},
+	) < 0
+}
+
+// SortVideos returns a copy of `streams` sorted from the lowest to the highest rank according to this preference.
+func (p Preference) SortVideos(streams VideoStreams) VideoStreams {
+	sorted := append(VideoStreams(nil), streams...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return p.lessVideo(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+// SortAudios returns a copy of `streams` sorted from the lowest to the highest rank according to this preference.
+func (p Preference) SortAudios(streams AudioStreams) AudioStreams {
+	sorted := append(AudioStreams(nil), streams...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return p.lessAudio(sorted[i], sorted[j])
+	})
+	return sorted
+}
@@ -0,0 +1,84 @@
+package gotube
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRank(t *testing.T) {
+	tests := []struct {
+		prefixes []string
+		value    string
+		want     int
+	}{
+		{DefaultPreference.VideoCodecs, "avc1.64001F", 3},
+		{DefaultPreference.VideoCodecs, "vp9", 2},
+		{DefaultPreference.VideoCodecs, "vp09.02.51.10", 2},
+		{DefaultPreference.VideoCodecs, "av01.0.08M.08", 1},
+		{DefaultPreference.VideoCodecs, "hev1", 0},
+		{DefaultPreference.AudioCodecs, "mp4a.40.2", 2},
+		{DefaultPreference.AudioCodecs, "opus", 1},
+	}
+	for _, test := range tests {
+		if got := rank(test.prefixes, test.value); got != test.want {
+			t.Errorf("rank(%v, %q) = %d, want %d", test.prefixes, test.value, got, test.want)
+		}
+	}
+}
+
+func TestSortVideos(t *testing.T) {
+	videos := testStreams(t).Videos()
+	newer := Preference{
+		VideoCodecs: []string{"av01", "vp9", "avc1"},
+		AudioCodecs: []string{"opus", "mp4a"},
+		Containers:  []string{"webm", "mp4"},
+	}
+	tests := []struct {
+		name       string
+		preference Preference
+		want       []int
+	}{
+		{"default", DefaultPreference, []int{160, 18, 398, 247, 22, 136, 399, 248, 137, 303, 299, 313, 337}},
+		{"newer codecs", newer, []int{160, 18, 22, 136, 247, 398, 137, 248, 399, 299, 303, 313, 337}},
+		{"no preference", Preference{}, []int{160, 18, 398, 22, 247, 136, 399, 248, 137, 303, 299, 313, 337}},
+	}
+	for _, test := range tests {
+		var got []int
+		for _, stream := range test.preference.SortVideos(videos) {
+			got = append(got, stream.Itag())
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("%v: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestBestAndWorst(t *testing.T) {
+	streams := testStreams(t)
+	before := fmt.Sprint(itags(streams))
+	tests := []struct {
+		name string
+		got  Stream
+		want int
+	}{
+		{"best video", streams.Videos().Best(), 337},
+		{"worst video", streams.Videos().Worst(), 160},
+		{"best 1080p30", streams.Filter(func(_ int, s Stream) bool { return s.Itag() < 299 }).Videos().Best(), 137},
+		{"best with audio", streams.Videos().WithAudio().Best(), 22},
+		{"best audio", streams.Audios().Best(), 251},
+		{"worst audio", streams.Audios().Worst(), 139},
+	}
+	for _, test := range tests {
+		if test.got == nil || test.got.Itag() != test.want {
+			t.Errorf("%v: got %v, want itag %d", test.name, test.got, test.want)
+		}
+	}
+	// ranking works on copies
+	if after := fmt.Sprint(itags(streams)); after != before {
+		t.Errorf("streams were reordered from %v to %v", before, after)
+	}
+	sorted := streams.Sort(func(a, b Stream) bool { return a.Itag() > b.Itag() })
+	if sorted[0].Itag() != 399 || fmt.Sprint(itags(streams)) != before {
+		t.Errorf("Sort() = %v, and the streams became %v", itags(sorted), itags(streams))
+	}
+}
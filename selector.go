@@ -0,0 +1,426 @@
+package gotube
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Selector is a parsed format selection expression, which selects one or more streams out of `Streams`.
+//
+// An expression consists of alternatives separated by '/', the first alternative that matches is selected.
+// Each alternative is either a single selection or two selections merged by '+' (e.g. a video and an audio).
+// A single selection is a base followed by any number of filters in brackets:
+//
+//	bestvideo[height<=1080][vcodec^=avc1]+bestaudio[ext=m4a]/best
+//
+// Bases are 'best' / 'worst' (streams with both video and audio), 'bestvideo' / 'worstvideo' (video-only streams),
+// 'bestaudio' / 'worstaudio' (audio-only streams), or an itag number. If the base is omitted, it defaults to 'best'.
+//
+// Filters compare a field of the stream with a value using one of the operators
+// '=', '!=', '<', '<=', '>', '>=' (numbers and strings), '^=' (starts with), '$=' (ends with) and '*=' (contains).
+// The fields are 'height', 'width', 'fps', 'bitrate', 'filesize', 'itag', 'asr' (sample rate), 'channels'
+// and 'duration' (in seconds) (numbers), and 'ext', 'container', 'vcodec', 'acodec', 'quality',
+// 'dynamic_range' (SDR, HDR10 or HLG), 'projection' (e.g. RECTANGULAR, EQUIRECTANGULAR), 'track' (audio track id),
+// 'language' (audio track language) and 'drc' (true or false) (strings). Numbers may have a 'k', 'M' or 'G' suffix.
+// A stream without the field never matches, unless the operator is followed by '?' (e.g. '[height<=?720]').
+type Selector struct {
+	expr         string
+	alternatives [][]selection
+}
+
+type selection struct {
+	base    string
+	itag    int
+	filters []filter
+}
+
+type filter struct {
+	field    string
+	op       string
+	value    string
+	optional bool
+}
+
+var (
+	selectionPattern = regexp.MustCompile(`^([a-z]+|\d+)?((?:\[[^\]]*\])*)$`)
+	filterPattern    = regexp.MustCompile(`^([a-z_]+)\s*(!=|<=|>=|\^=|\$=|\*=|=|<|>)(\??)\s*(.+)$`)
+)
+
+var selectorBases = map[string]bool{
+	"best": true, "worst": true,
+	"bestvideo": true, "worstvideo": true,
+	"bestaudio": true, "worstaudio": true,
+}
+
+var numericFields = map[string]bool{
+	"height": true, "width": true, "fps": true, "bitrate": true,
+	"filesize": true, "itag": true, "asr": true, "channels": true, "duration": true,
+}
+
+var stringFields = map[string]bool{
+	"ext": true, "container": true, "vcodec": true, "acodec": true, "quality": true,
+	"dynamic_range": true, "projection": true, "track": true, "language": true, "drc": true,
+}
+
+// ParseSelector parses the format selection expression `expr` (see `Selector`).
+// Spaces are allowed around '/' and '+', and within the brackets of the filters, where they are part of the values
+// (e.g. '[quality=1080p60 HDR]').
+func ParseSelector(expr string) (*Selector, error) {
+	tokens, err := tokenizeSelector(expr)
+	if err != nil {
+		return nil, fmt.Errorf("selector '%v': %v", expr, err)
+	}
+	selector := &Selector{expr: expr}
+	var alternative []selection
+	expectSelection := true
+	for _, token := range tokens {
+		switch {
+		case token == "/" || token == "+":
+			if expectSelection {
+				return nil, fmt.Errorf("selector '%v': missing selection before '%v'", expr, token)
+			}
+			if token == "/" {
+				selector.alternatives = append(selector.alternatives, alternative)
+				alternative = nil
+			}
+			expectSelection = true
+		case !expectSelection:
+			return nil, fmt.Errorf("selector '%v': missing '/' or '+' before '%v'", expr, token)
+		default:
+			sel, err := parseSelection(token)
+			if err != nil {
+				return nil, fmt.Errorf("selector '%v': %v", expr, err)
+			}
+			if alternative = append(alternative, sel); len(alternative) > 2 {
+				return nil, fmt.Errorf("selector '%v': at most two selections can be merged", expr)
+			}
+			expectSelection = false
+		}
+	}
+	if expectSelection {
+		return nil, fmt.Errorf("selector '%v': missing selection at the end", expr)
+	}
+	selector.alternatives = append(selector.alternatives, alternative)
+	return selector, nil
+}
+
+// tokenizeSelector splits `expr` into the operators '/' and '+' and the selections in between,
+// each of which is a base followed by filters in brackets, taken as is.
+func tokenizeSelector(expr string) (tokens []string, err error) {
+	start, depth := -1, 0
+	end := func(i int) {
+		if start >= 0 {
+			tokens = append(tokens, expr[start:i])
+			start = -1
+		}
+	}
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case depth > 0:
+			if c == ']' {
+				depth--
+			}
+			continue
+		case c == ' ' || c == '\t':
+			end(i)
+			continue
+		case c == '/' || c == '+':
+			end(i)
+			tokens = append(tokens, string(c))
+			continue
+		case c == ']':
+			return nil, fmt.Errorf("unexpected ']' at %d", i)
+		case c == '[':
+			depth++
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("missing ']'")
+	}
+	end(len(expr))
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return tokens, nil
+}
+
+func parseSelection(s string) (sel selection, err error) {
+	if s == "" {
+		return sel, fmt.Errorf("empty selection")
+	}
+	matches := selectionPattern.FindStringSubmatch(s)
+	if len(matches) == 0 {
+		return sel, fmt.Errorf("invalid selection '%v'", s)
+	}
+	sel.base = matches[1]
+	if sel.base == "" {
+		sel.base = "best"
+	}
+	if itag, err := strconv.Atoi(sel.base); err == nil {
+		sel.itag = itag
+	} else if !selectorBases[sel.base] {
+		return sel, fmt.Errorf("unknown base '%v'", sel.base)
+	}
+	if matches[2] == "" {
+		return sel, nil
+	}
+	for _, f := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(matches[2], "["), "]"), "][") {
+		fm := filterPattern.FindStringSubmatch(strings.TrimSpace(f))
+		if len(fm) == 0 {
+			return sel, fmt.Errorf("invalid filter '[%v]'", f)
+		}
+		flt := filter{field: fm[1], op: fm[2], optional: fm[3] == "?", value: fm[4]}
+		switch {
+		case numericFields[flt.field]:
+			if _, err := parseNumber(flt.value); err != nil {
+				return sel, fmt.Errorf("invalid number '%v' in filter '[%v]'", flt.value, f)
+			}
+			if strings.ContainsAny(flt.op, "^$*") {
+				return sel, fmt.Errorf("operator '%v' cannot be used on numeric field '%v'", flt.op, flt.field)
+			}
+		case stringFields[flt.field]:
+		default:
+			return sel, fmt.Errorf("unknown field '%v'", flt.field)
+		}
+		sel.filters = append(sel.filters, flt)
+	}
+	return sel, nil
+}
+
+func parseNumber(s string) (float64, error) {
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(s, "k") || strings.HasSuffix(s, "K"):
+		multiplier = 1e3
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1e6
+	case strings.HasSuffix(s, "G"):
+		multiplier = 1e9
+	}
+	if multiplier != 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	return n * multiplier, err
+}
+
+// String returns the expression of this selector.
+func (selector *Selector) String() string {
+	return selector.expr
+}
+
+// Select returns the streams selected by the first matching alternative (in the order they are written),
+// or nil if none of the alternatives matches.
+func (selector *Selector) Select(streams Streams) Streams {
+	for _, alternative := range selector.alternatives {
+		var results Streams
+		for _, sel := range alternative {
+			stream := sel.pick(streams)
+			if stream == nil {
+				results = nil
+				break
+			}
+			results = append(results, stream)
+		}
+		if results != nil {
+			return results
+		}
+	}
+	return nil
+}
+
+// Select parses the format selection expression `expr` and returns the streams it selects (see `Selector`).
+// It returns an error if the expression is invalid or nothing matches.
+func (streams Streams) Select(expr string) (Streams, error) {
+	selector, err := ParseSelector(expr)
+	if err != nil {
+		return nil, err
+	}
+	results := selector.Select(streams)
+	if results == nil {
+		return nil, fmt.Errorf("no stream matches selector '%v'", expr)
+	}
+	return results, nil
+}
+
+func (sel selection) pick(streams Streams) Stream {
+	candidates := streams.Filter(func(_ int, stream Stream) bool {
+		for _, f := range sel.filters {
+			if !f.match(stream) {
+				return false
+			}
+		}
+		return true
+	})
+	if sel.itag != 0 {
+		return candidates.Itag(sel.itag)
+	}
+	switch sel.base {
+	case "best", "worst":
+		videos := candidates.Videos().WithAudio()
+		if sel.base == "best" {
+			return nilStream(videos.Best())
+		}
+		return nilStream(videos.Worst())
+	case "bestvideo", "worstvideo":
+		var videos VideoStreams
+		for _, s := range candidates.Videos() {
+			if !s.HasAudio {
+				videos = append(videos, s)
+			}
+		}
+		if sel.base == "bestvideo" {
+			return nilStream(videos.Best())
+		}
+		return nilStream(videos.Worst())
+	case "bestaudio", "worstaudio":
+		audios := candidates.Audios()
+		if sel.base == "bestaudio" {
+			return nilStream(audios.Best())
+		}
+		return nilStream(audios.Worst())
+	}
+	return nil
+}
+
+// nilStream converts a typed nil pointer into a nil `Stream` interface.
+func nilStream(stream Stream) Stream {
+	switch s := stream.(type) {
+	case *VideoStream:
+		if s == nil {
+			return nil
+		}
+	case *AudioStream:
+		if s == nil {
+			return nil
+		}
+	}
+	return stream
+}
+
+func (f filter) match(stream Stream) bool {
+	value, ok := streamField(stream, f.field)
+	if !ok {
+		return f.optional
+	}
+	if n, isNumber := value.(float64); isNumber {
+		target, _ := parseNumber(f.value)
+		switch f.op {
+		case "=":
+			return n == target
+		case "!=":
+			return n != target
+		case "<":
+			return n < target
+		case "<=":
+			return n <= target
+		case ">":
+			return n > target
+		case ">=":
+			return n >= target
+		}
+		return false
+	}
+	s := value.(string)
+	switch f.op {
+	case "=":
+		return s == f.value
+	case "!=":
+		return s != f.value
+	case "<":
+		return s < f.value
+	case "<=":
+		return s <= f.value
+	case ">":
+		return s > f.value
+	case ">=":
+		return s >= f.value
+	case "^=":
+		return strings.HasPrefix(s, f.value)
+	case "$=":
+		return strings.HasSuffix(s, f.value)
+	case "*=":
+		return strings.Contains(s, f.value)
+	}
+	return false
+}
+
+// streamField returns the value (either float64 or string) of the selector field of `stream`,
+// and false if the stream does not have such field.
+func streamField(stream Stream, field string) (interface{}, bool) {
+	switch field {
+	case "itag":
+		return float64(stream.Itag()), true
+	case "container":
+		return stream.Subtype(), true
+	}
+	switch s := stream.(type) {
+	case *VideoStream:
+		switch field {
+		case "height":
+			return float64(s.Height), s.Height != 0
+		case "width":
+			return float64(s.Width), s.Width != 0
+		case "fps":
+			return float64(s.FPS), s.FPS != 0
+		case "duration":
+			return s.Duration.Seconds(), s.Duration != 0
+		case "dynamic_range":
+			return s.DynamicRange, true
+		case "projection":
+			return s.ProjectionType, s.ProjectionType != ""
+		case "bitrate":
+			return float64(s.Bitrate), s.Bitrate != 0
+		case "filesize":
+			return float64(s.FileSize), s.FileSize != 0
+		case "ext":
+			return s.Subtype(), true
+		case "vcodec":
+			return s.VideoCodec, true
+		case "acodec":
+			if !s.HasAudio {
+				return "none", true
+			}
+			return s.AudioCodec, true
+		case "quality":
+			return s.QualityLabel, true
+		}
+	case *AudioStream:
+		switch field {
+		case "bitrate":
+			return float64(s.Bitrate), s.Bitrate != 0
+		case "filesize":
+			return float64(s.FileSize), s.FileSize != 0
+		case "asr":
+			return float64(s.SampleRate), s.SampleRate != 0
+		case "duration":
+			return s.Duration.Seconds(), s.Duration != 0
+		case "track":
+			return s.AudioTrackID, s.AudioTrackID != ""
+		case "language":
+			return s.Language, s.Language != ""
+		case "drc":
+			return strconv.FormatBool(s.IsDRC), true
+		case "channels":
+			return float64(s.Channels), s.Channels != 0
+		case "ext":
+			if s.Subtype() == "mp4" {
+				return "m4a", true
+			}
+			return s.Subtype(), true
+		case "vcodec":
+			return "none", true
+		case "acodec":
+			return s.Codec, true
+		case "quality":
+			return s.Quality.String(), true
+		}
+	}
+	return nil, false
+}
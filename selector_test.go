@@ -0,0 +1,60 @@
+package gotube
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	streams := testStreams(t)
+	tests := []struct {
+		expr string
+		want []int
+	}{
+		{"best", []int{22}},
+		{"worst", []int{18}},
+		{"140", []int{140}},
+		{"bestaudio[ext=m4a]", []int{140}},
+		{"worstaudio[acodec=opus]", []int{249}},
+		{"bestvideo[height<=720][vcodec^=avc1]", []int{136}},
+		{"bestvideo[vcodec^=av01][filesize<50M]", []int{399}},
+		{"bestvideo[vcodec^=av01][filesize<30M]", []int{398}},
+		{"bestvideo[quality=2160p60 HDR]", []int{337}},
+		{"[quality=360p]", []int{18}},
+		{"bestvideo[dynamic_range=HDR10]", []int{337}},
+		{"best[filesize>1M]", []int{18}},
+		{"best[filesize>?1M]", []int{22}},
+		// '+' merges two selections
+		{"bestvideo[vcodec^=avc1]+bestaudio[ext=m4a]", []int{299, 140}},
+		{"bestaudio[acodec=opus] + bestvideo[ext=webm][fps<60]", []int{251, 313}},
+		// '/' falls back to the next alternative, and binds looser than '+'
+		{"999/251", []int{251}},
+		{"bestvideo[height>4320]/bestvideo[height<=1080][fps<=30][ext=webm]", []int{248}},
+		{"bestvideo[height>4320]+bestaudio/best", []int{22}},
+		{"bestvideo[vcodec^=avc1]+bestaudio[ext=m4a]/best", []int{299, 140}},
+		{"bestvideo[vcodec^=avc1] + bestaudio[acodec=flac] / bestvideo[vcodec^=avc1] + bestaudio[ext=m4a]", []int{299, 140}},
+		{"999", nil},
+	}
+	for _, test := range tests {
+		selector, err := ParseSelector(test.expr)
+		if err != nil {
+			t.Errorf("ParseSelector(%q): %v", test.expr, err)
+			continue
+		}
+		if got := itags(selector.Select(streams)); fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("%q selected %v, want %v", test.expr, got, test.want)
+		}
+	}
+}
+
+func TestParseSelectorInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"", " ", "/best", "best/", "best//worst", "+best", "best+", "best++worst",
+		"bestvideo+bestaudio+bestaudio", "best video", "bestvideo [height<=720]",
+		"best[height<=720", "best]", "best[]", "best[foo=1]", "best[height^=7]", "best[height<=abc]", "unknown",
+	} {
+		if _, err := ParseSelector(expr); err == nil {
+			t.Errorf("ParseSelector(%q): expected an error", expr)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package gotube
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tnychn/gotube/data"
+)
+
+// Snapshot is the serializable state of an initialized video.
+// It carries everything needed to restore the video (including its streams and captions) without initializing it again.
+type Snapshot struct {
+	ID              string               `json:"id"`
+	IsAgeRestricted bool                 `json:"is_age_restricted"`
+	PlayerURL       string               `json:"player_url"`
+	PlayerResponse  *data.PlayerResponse `json:"player_response"`
+	FetchedAt       time.Time            `json:"fetched_at"`
+	InitialData     *data.InitialData    `json:"initial_data,omitempty"`
+	RegionsAllowed  []string             `json:"regions_allowed,omitempty"`
+}
+
+// Snapshot returns the snapshot of this video. `Initialize()` must be called beforehand.
+func (video *Video) Snapshot() *Snapshot {
+	if video.playerResponse == nil {
+		panic("player response is nil: Initialize() must be called beforehand")
+	}
+	return &Snapshot{
+		ID:              video.ID,
+		IsAgeRestricted: video.IsAgeRestricted,
+		PlayerURL:       video.jsURL,
+		PlayerResponse:  video.playerResponse,
+		InitialData:     video.initialData,
+		RegionsAllowed:  video.regionsAllowed,
+		FetchedAt:       video.fetchedAt,
+	}
+}
+
+// RestoreVideo returns an initialized video restored from `snapshot`.
+// Note that the stream urls of the restored video expire at the same time as those of the original one.
+func RestoreVideo(snapshot *Snapshot) (*Video, error) {
+	if snapshot.PlayerResponse == nil {
+		return nil, fmt.Errorf("snapshot of video %v has no player response", snapshot.ID)
+	}
+	video, err := NewVideo(snapshot.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	video.IsAgeRestricted = snapshot.IsAgeRestricted
+	video.jsURL = snapshot.PlayerURL
+	video.playerResponse = snapshot.PlayerResponse
+	video.fetchedAt = snapshot.FetchedAt
+	video.initialData = snapshot.InitialData
+	video.regionsAllowed = snapshot.RegionsAllowed
+	if err = video.obtainBasicInfo(); err != nil {
+		return nil, video.wrapError("obtain info", err)
+	}
+	return video, nil
+}
+
+// MarshalJSON marshals the snapshot of this video (see `Snapshot()`).
+func (video *Video) MarshalJSON() ([]byte, error) {
+	if video.playerResponse == nil {
+		return nil, fmt.Errorf("video %v is not initialized", video.ID)
+	}
+	return json.Marshal(video.Snapshot())
+}
+
+// UnmarshalJSON restores this video from a marshaled snapshot (see `RestoreVideo()`).
+func (video *Video) UnmarshalJSON(b []byte) error {
+	var snapshot Snapshot
+	if err := json.Unmarshal(b, &snapshot); err != nil {
+		return err
+	}
+	restored, err := RestoreVideo(&snapshot)
+	if err != nil {
+		return err
+	}
+	*video = *restored
+	return nil
+}
@@ -0,0 +1,252 @@
+package gotube
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tnychn/gotube/errors"
+	"github.com/tnychn/gotube/utils"
+)
+
+// Storyboard represents the storyboard of a YouTube video, which are the preview thumbnails shown when scrubbing.
+// It consists of levels, each with frames of a different size arranged in sprite sheets.
+type Storyboard struct {
+	Levels []*StoryboardLevel `json:"levels"`
+}
+
+// Best returns the level with the largest frames, or nil if there is none.
+func (storyboard *Storyboard) Best() (best *StoryboardLevel) {
+	for _, level := range storyboard.Levels {
+		if best == nil || level.Width*level.Height > best.Width*best.Height {
+			best = level
+		}
+	}
+	return
+}
+
+// StoryboardLevel represents a level of a storyboard.
+// The frames are arranged in sheets of `Columns` x `Rows` frames, row by row.
+type StoryboardLevel struct {
+	Index   int `json:"index"`
+	Width   int `json:"width"`
+	Height  int `json:"height"`
+	Count   int `json:"count"`
+	Columns int `json:"columns"`
+	Rows    int `json:"rows"`
+	// Interval is the time between two frames.
+	Interval time.Duration `json:"interval"`
+	// URLTemplate is the url of the sheets, with '$M' in place of the index of the sheet.
+	URLTemplate string `json:"url_template"`
+}
+
+// StoryboardFrame is a frame of a storyboard level, shown from `Time` of the video.
+type StoryboardFrame struct {
+	Index int           `json:"index"`
+	Time  time.Duration `json:"time"`
+	Path  string        `json:"path,omitempty"`
+}
+
+type storyboardLevelJSON StoryboardLevel
+
+// MarshalJSON marshals this level with its interval in seconds.
+func (level StoryboardLevel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		*storyboardLevelJSON
+		Interval float64 `json:"interval"`
+	}{(*storyboardLevelJSON)(&level), seconds(level.Interval)})
+}
+
+// UnmarshalJSON unmarshals this level with its interval in seconds (see `MarshalJSON()`).
+func (level *StoryboardLevel) UnmarshalJSON(b []byte) error {
+	v := struct {
+		*storyboardLevelJSON
+		Interval float64 `json:"interval"`
+	}{storyboardLevelJSON: (*storyboardLevelJSON)(level)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	level.Interval = fromSeconds(v.Interval)
+	return nil
+}
+
+type storyboardFrameJSON StoryboardFrame
+
+// MarshalJSON marshals this frame with its time in seconds.
+func (frame StoryboardFrame) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		*storyboardFrameJSON
+		Time float64 `json:"time"`
+	}{(*storyboardFrameJSON)(&frame), seconds(frame.Time)})
+}
+
+// UnmarshalJSON unmarshals this frame with its time in seconds (see `MarshalJSON()`).
+func (frame *StoryboardFrame) UnmarshalJSON(b []byte) error {
+	v := struct {
+		*storyboardFrameJSON
+		Time float64 `json:"time"`
+	}{storyboardFrameJSON: (*storyboardFrameJSON)(frame)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	frame.Time = fromSeconds(v.Time)
+	return nil
+}
+
+// ParseStoryboardSpec parses the storyboard specification of a video of the given duration.
+// The specification is a url template followed by the levels separated by '|',
+// each as 'width#height#count#columns#rows#interval(ms)#name#signature'.
+func ParseStoryboardSpec(spec string, duration time.Duration) (*Storyboard, error) {
+	parts := strings.Split(spec, "|")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid storyboard spec: no levels")
+	}
+	storyboard := new(Storyboard)
+	for i, part := range parts[1:] {
+		fields := strings.Split(part, "#")
+		if len(fields) < 8 {
+			return nil, fmt.Errorf("invalid storyboard spec: level %d has %d fields", i, len(fields))
+		}
+		var numbers [6]int
+		for j := range numbers {
+			n, err := strconv.Atoi(fields[j])
+			if err != nil {
+				return nil, fmt.Errorf("invalid storyboard spec: level %d: %v", i, err)
+			}
+			numbers[j] = n
+		}
+		level := &StoryboardLevel{
+			Index:    i,
+			Width:    numbers[0],
+			Height:   numbers[1],
+			Count:    numbers[2],
+			Columns:  numbers[3],
+			Rows:     numbers[4],
+			Interval: time.Duration(numbers[5]) * time.Millisecond,
+		}
+		if level.Columns <= 0 || level.Rows <= 0 {
+			return nil, fmt.Errorf("invalid storyboard spec: level %d has no frames per sheet", i)
+		}
+		// the interval is omitted by the levels spreading their frames across the whole video
+		if level.Interval == 0 && level.Count > 0 {
+			level.Interval = duration / time.Duration(level.Count)
+		}
+		level.URLTemplate = strings.ReplaceAll(strings.ReplaceAll(parts[0], "$L", strconv.Itoa(i)), "$N", fields[6])
+		separator := "?"
+		if strings.Contains(level.URLTemplate, "?") {
+			separator = "&"
+		}
+		level.URLTemplate += separator + "sigh=" + fields[7]
+		storyboard.Levels = append(storyboard.Levels, level)
+	}
+	return storyboard, nil
+}
+
+// Storyboard parses and returns the storyboard of this video.
+// The storyboards of live content ('url#width#height#columns#rows', growing as the stream goes on) are unsupported.
+func (video *Video) Storyboard() (*Storyboard, error) {
+	if video.playerResponse == nil {
+		panic("player response is nil: Initialize() must be called beforehand")
+	}
+	storyboards := video.playerResponse.Storyboards
+	spec := storyboards.PlayerStoryboardSpecRenderer.Spec
+	if spec == "" {
+		if storyboards.PlayerLiveStoryboardSpecRenderer.Spec != "" {
+			return nil, errors.VideoUnsupportedError{VideoID: video.ID}
+		}
+		return nil, fmt.Errorf("video %v has no storyboard", video.ID)
+	}
+	return ParseStoryboardSpec(spec, video.Duration)
+}
+
+// SheetCount returns the number of sheets of this level.
+func (level *StoryboardLevel) SheetCount() int {
+	perSheet := level.Columns * level.Rows
+	return (level.Count + perSheet - 1) / perSheet
+}
+
+// SheetURL returns the url of the sheet of the given index.
+func (level *StoryboardLevel) SheetURL(index int) string {
+	return strings.ReplaceAll(level.URLTemplate, "$M", strconv.Itoa(index))
+}
+
+// Frame returns the frame of the given index, along with the index of its sheet and its bounds within the sheet.
+func (level *StoryboardLevel) Frame(index int) (frame StoryboardFrame, sheet int, bounds image.Rectangle) {
+	perSheet := level.Columns * level.Rows
+	sheet = index / perSheet
+	i := index % perSheet
+	x, y := i%level.Columns*level.Width, i/level.Columns*level.Height
+	return StoryboardFrame{Index: index, Time: time.Duration(index) * level.Interval},
+		sheet, image.Rect(x, y, x+level.Width, y+level.Height)
+}
+
+// DownloadFrames downloads the sheets of this level and slices them into frames,
+// which are saved as 'frame_<index>_<milliseconds>.jpg' files in `destdir`.
+// If `destdir` is empty, it defaults to the current directory.
+// `onFrame` (if not nil) is called after each frame is saved.
+func (level *StoryboardLevel) DownloadFrames(destdir string, onFrame func(frame StoryboardFrame)) (frames []StoryboardFrame, err error) {
+	if destdir == "" {
+		if destdir, err = os.Getwd(); err != nil {
+			return
+		}
+	}
+	if destdir, err = filepath.Abs(destdir); err != nil {
+		return
+	}
+	type subImager interface {
+		SubImage(r image.Rectangle) image.Image
+	}
+	var sheet subImager
+	current := -1
+	for i := 0; i < level.Count; i++ {
+		frame, index, bounds := level.Frame(i)
+		if index != current {
+			u := level.SheetURL(index)
+			content, err := utils.HttpFetch(u)
+			if err != nil {
+				return frames, errors.OpError{Op: "fetch storyboard", URL: u, Err: err}
+			}
+			img, err := jpeg.Decode(bytes.NewReader(content))
+			if err != nil {
+				return frames, errors.OpError{Op: "decode storyboard", URL: u, Err: err}
+			}
+			s, ok := img.(subImager)
+			if !ok {
+				return frames, fmt.Errorf("storyboard sheet %d cannot be sliced", index)
+			}
+			sheet, current = s, index
+		}
+		// the last sheet is not necessarily full
+		if !bounds.In(sheet.(image.Image).Bounds()) {
+			break
+		}
+		frame.Path = filepath.Join(destdir, fmt.Sprintf("frame_%05d_%d.jpg", frame.Index, frame.Time.Milliseconds()))
+		if err = saveJPEG(frame.Path, sheet.SubImage(bounds)); err != nil {
+			return
+		}
+		frames = append(frames, frame)
+		if onFrame != nil {
+			onFrame(frame)
+		}
+	}
+	return
+}
+
+func saveJPEG(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = jpeg.Encode(file, img, &jpeg.Options{Quality: 90}); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
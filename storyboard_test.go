@@ -0,0 +1,105 @@
+package gotube
+
+import (
+	"encoding/json"
+	"image"
+	"testing"
+	"time"
+
+	"github.com/tnychn/gotube/errors"
+)
+
+// storyboardSpec is in the format of the storyboard specs of the player responses:
+// the first level spreads its frames across the whole video (interval 0) in a single sheet named 'default',
+// while the others have a fixed interval and their sheets named 'M<index>'.
+const storyboardSpec = "https://i.ytimg.com/sb/dQw4w9WgXcQ/storyboard3_L$L/$N.jpg?sqp=-oaymwENSDfyq4qpAwVwAcABBqLzl_8DBgjE0Z_HBQ==" +
+	"|48#27#100#10#10#0#default#rs$AOn4CLBv8Cw6Xb5W4Ht6_VhYxWVc3dDN0Q" +
+	"|80#45#107#10#10#2000#M$M#rs$AOn4CLD7E8t6vF0DtwJjtj2mMv5lX-EDWg" +
+	"|160#90#107#5#5#2000#M$M#rs$AOn4CLDg8jVzXk3bmC3QvC8K7lgNjwtIKg"
+
+func TestParseStoryboardSpec(t *testing.T) {
+	storyboard, err := ParseStoryboardSpec(storyboardSpec, 212*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const base = "https://i.ytimg.com/sb/dQw4w9WgXcQ/storyboard3_L"
+	const sqp = "?sqp=-oaymwENSDfyq4qpAwVwAcABBqLzl_8DBgjE0Z_HBQ=="
+	tests := []struct {
+		level    StoryboardLevel
+		sheets   int
+		sheetURL string
+		// the sheet and bounds of the last frame, which is in a partial sheet for the levels 1 and 2
+		lastSheet  int
+		lastBounds image.Rectangle
+	}{
+		{
+			StoryboardLevel{Index: 0, Width: 48, Height: 27, Count: 100, Columns: 10, Rows: 10, Interval: 2120 * time.Millisecond,
+				URLTemplate: base + "0/default.jpg" + sqp + "&sigh=rs$AOn4CLBv8Cw6Xb5W4Ht6_VhYxWVc3dDN0Q"},
+			1, base + "0/default.jpg" + sqp + "&sigh=rs$AOn4CLBv8Cw6Xb5W4Ht6_VhYxWVc3dDN0Q",
+			0, image.Rect(432, 243, 480, 270),
+		},
+		{
+			StoryboardLevel{Index: 1, Width: 80, Height: 45, Count: 107, Columns: 10, Rows: 10, Interval: 2 * time.Second,
+				URLTemplate: base + "1/M$M.jpg" + sqp + "&sigh=rs$AOn4CLD7E8t6vF0DtwJjtj2mMv5lX-EDWg"},
+			2, base + "1/M1.jpg" + sqp + "&sigh=rs$AOn4CLD7E8t6vF0DtwJjtj2mMv5lX-EDWg",
+			1, image.Rect(480, 0, 560, 45),
+		},
+		{
+			StoryboardLevel{Index: 2, Width: 160, Height: 90, Count: 107, Columns: 5, Rows: 5, Interval: 2 * time.Second,
+				URLTemplate: base + "2/M$M.jpg" + sqp + "&sigh=rs$AOn4CLDg8jVzXk3bmC3QvC8K7lgNjwtIKg"},
+			5, base + "2/M1.jpg" + sqp + "&sigh=rs$AOn4CLDg8jVzXk3bmC3QvC8K7lgNjwtIKg",
+			4, image.Rect(160, 90, 320, 180),
+		},
+	}
+	if len(storyboard.Levels) != len(tests) {
+		t.Fatalf("got %d levels, want %d", len(storyboard.Levels), len(tests))
+	}
+	for i, test := range tests {
+		level := storyboard.Levels[i]
+		if *level != test.level {
+			t.Errorf("level %d = %+v, want %+v", i, *level, test.level)
+		}
+		if sheets := level.SheetCount(); sheets != test.sheets {
+			t.Errorf("level %d has %d sheets, want %d", i, sheets, test.sheets)
+		}
+		if u := level.SheetURL(1); u != test.sheetURL {
+			t.Errorf("level %d sheet 1 = %v, want %v", i, u, test.sheetURL)
+		}
+		frame, sheet, bounds := level.Frame(level.Count - 1)
+		if want := time.Duration(level.Count-1) * test.level.Interval; frame.Time != want {
+			t.Errorf("level %d last frame at %v, want %v", i, frame.Time, want)
+		}
+		if sheet != test.lastSheet || bounds != test.lastBounds {
+			t.Errorf("level %d last frame in sheet %d at %v, want sheet %d at %v", i, sheet, bounds, test.lastSheet, test.lastBounds)
+		}
+	}
+	if best := storyboard.Best(); best != storyboard.Levels[2] {
+		t.Errorf("best level = %d, want 2", best.Index)
+	}
+}
+
+func TestParseStoryboardSpecInvalid(t *testing.T) {
+	const u = "https://i.ytimg.com/sb/dQw4w9WgXcQ/storyboard3_L$L/$N.jpg"
+	for _, spec := range []string{
+		u,
+		u + "|48#27#100#10#10#0#default",
+		u + "|48#27#100#0#10#0#default#rs$AOn4CLBv8Cw6Xb5W4Ht6_VhYxWVc3dDN0Q",
+		u + "|48#27#100#10#0#0#default#rs$AOn4CLBv8Cw6Xb5W4Ht6_VhYxWVc3dDN0Q",
+		u + "|48#27#lots#10#10#0#default#rs$AOn4CLBv8Cw6Xb5W4Ht6_VhYxWVc3dDN0Q",
+	} {
+		if storyboard, err := ParseStoryboardSpec(spec, 212*time.Second); err == nil {
+			t.Errorf("ParseStoryboardSpec(%q) = %+v, want an error", spec, storyboard)
+		}
+	}
+}
+
+func TestLiveStoryboard(t *testing.T) {
+	video := &Video{ID: "jfKfPfyJRdk"}
+	content := `{"storyboards":{"playerLiveStoryboardSpecRenderer":{"spec":"https://i.ytimg.com/sb/jfKfPfyJRdk/storyboard_live_90_3x3_b2/M$M.jpg?rs=AOn4CLC6pZGvPTUz-ZuN0CqCSGVqB2nmPg#159#90#3#3"}}}`
+	if err := json.Unmarshal([]byte(content), &video.playerResponse); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := video.Storyboard(); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("got %v, want a VideoUnsupportedError", err)
+	}
+}
@@ -1,6 +1,7 @@
 package gotube
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"sort"
@@ -10,10 +11,21 @@ import (
 	"github.com/tnychn/gotube/data"
 	"github.com/tnychn/gotube/decrypt"
 	"github.com/tnychn/gotube/download"
+	"github.com/tnychn/gotube/errors"
 	"github.com/tnychn/gotube/utils"
 )
 
-func getDownloadURL(stream Stream) (string, error) {
+func wrapStreamError(stream Stream, op, u string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return errors.OpError{Op: op, URL: u, VideoID: stream.ParentVideo().ID, Itag: stream.Itag(), Err: err}
+}
+
+func getDownloadURL(stream Stream) (dlurl string, err error) {
+	defer func() {
+		err = wrapStreamError(stream, "get download url", "", err)
+	}()
 	video := stream.ParentVideo()
 	metadata := stream.Metadata()
 	cipher := metadata["cipher"].(string)
@@ -36,21 +48,13 @@ func getDownloadURL(stream Stream) (string, error) {
 
 	// decrypt encrypted stream url
 	if isEncrypted {
-		if video.js == "" {
-			content, err := utils.HttpFetch(video.jsURL)
-			if err != nil {
-				return "", err
-			}
-			video.js = string(content)
-		}
-		if video.decryption == nil {
-			video.decryption, err = decrypt.NewDecryption(video.js)
-			if err != nil {
-				return "", err
-			}
+		if err = video.loadDecryption(); err != nil {
+			return "", err
 		}
 		signature, err := video.decryption.DecryptSignature(s)
 		if err != nil {
+			// the signature may come from an outdated cached player response
+			video.dropCache()
 			return "", err
 		}
 		if !strings.Contains(u, "&ratebypass=") {
@@ -62,6 +66,34 @@ func getDownloadURL(stream Stream) (string, error) {
 	return u, nil
 }
 
+// loadDecryption fetches the player of `video` and compiles its decryption, unless it is already done.
+// If the decryption cannot be compiled from a cached player, the player is dropped from the cache and fetched again.
+func (video *Video) loadDecryption() error {
+	if video.decryption != nil {
+		return nil
+	}
+	for {
+		cached := false
+		if video.js == "" {
+			js, fromCache, err := fetchPlayer(video.jsURL)
+			if err != nil {
+				return errors.OpError{Op: "fetch player", URL: video.jsURL, Err: err}
+			}
+			video.js, cached = js, fromCache
+		}
+		decryption, err := decrypt.NewDecryption(video.js)
+		if err == nil {
+			video.decryption = decryption
+			return nil
+		}
+		if !cached {
+			return err
+		}
+		_ = responseCache.Delete(playerCacheKey(video.jsURL))
+		video.js = ""
+	}
+}
+
 // VideoStreams represents a sequence of streams of type 'video' (can be with / without audio).
 type VideoStreams []*VideoStream
 
@@ -75,6 +107,36 @@ func (streams VideoStreams) WithAudio() (videoStreams VideoStreams) {
 	return
 }
 
+// HDR returns a copy of `VideoStreams` containing only `VideoStream` objects in high dynamic range.
+func (streams VideoStreams) HDR() (videoStreams VideoStreams) {
+	for _, s := range streams {
+		if s.IsHDR() {
+			videoStreams = append(videoStreams, s)
+		}
+	}
+	return
+}
+
+// FPS returns a copy of `VideoStreams` containing only `VideoStream` objects with a frame rate of at least `min`.
+func (streams VideoStreams) FPS(min int) (videoStreams VideoStreams) {
+	for _, s := range streams {
+		if s.FPS >= min {
+			videoStreams = append(videoStreams, s)
+		}
+	}
+	return
+}
+
+// Rectangular returns a copy of `VideoStreams` without 360° and VR `VideoStream` objects.
+func (streams VideoStreams) Rectangular() (videoStreams VideoStreams) {
+	for _, s := range streams {
+		if !s.Is360() {
+			videoStreams = append(videoStreams, s)
+		}
+	}
+	return
+}
+
 // First returns the first `VideoStream` object in this `VideoStreams`.
 func (streams VideoStreams) First() *VideoStream {
 	if len(streams) == 0 {
@@ -91,22 +153,67 @@ func (streams VideoStreams) Last() *VideoStream {
 	return streams[len(streams)-1]
 }
 
-// Best returns the `VideoStream` object with the highest resolution.
-func (streams VideoStreams) Best() *VideoStream { return streams.sortByResolution().Last() }
+// Sorted returns a copy of this `VideoStreams` sorted from the lowest to the highest rank,
+// that is by resolution, then frame rate, then codec and container preference (see `SetPreference()`), then bitrate.
+func (streams VideoStreams) Sorted() VideoStreams { return preference.SortVideos(streams) }
 
-// Worst returns the `VideoStream` object with the lowest resolution.
-func (streams VideoStreams) Worst() *VideoStream { return streams.sortByResolution().First() }
+// Best returns the `VideoStream` object with the highest rank (see `Sorted()`).
+func (streams VideoStreams) Best() *VideoStream { return streams.Sorted().Last() }
 
-func (streams VideoStreams) sortByResolution() VideoStreams {
-	sort.Slice(streams, func(i, j int) bool {
-		return streams[i].Height <= streams[j].Height && streams[i].Bitrate < streams[j].Bitrate
-	})
-	return streams
-}
+// Worst returns the `VideoStream` object with the lowest rank (see `Sorted()`).
+func (streams VideoStreams) Worst() *VideoStream { return streams.Sorted().First() }
 
 // AudioStreams represents a sequence of streams of type 'audio'.
 type AudioStreams []*AudioStream
 
+// WithoutDRC returns a copy of `AudioStreams` without dynamic range compressed (DRC) `AudioStream` objects.
+func (streams AudioStreams) WithoutDRC() (audioStreams AudioStreams) {
+	for _, s := range streams {
+		if !s.IsDRC {
+			audioStreams = append(audioStreams, s)
+		}
+	}
+	return
+}
+
+// Language returns a copy of `AudioStreams` containing only `AudioStream` objects of the audio tracks in the given language.
+// The language code matches its regional variants as well (e.g. 'en' matches 'en-US').
+func (streams AudioStreams) Language(code string) (audioStreams AudioStreams) {
+	for _, s := range streams {
+		if matchLanguage(s.Language, code) {
+			audioStreams = append(audioStreams, s)
+		}
+	}
+	return
+}
+
+// Default returns a copy of `AudioStreams` containing only `AudioStream` objects of the default (i.e. original) audio track.
+func (streams AudioStreams) Default() (audioStreams AudioStreams) {
+	for _, s := range streams {
+		if s.IsDefaultTrack {
+			audioStreams = append(audioStreams, s)
+		}
+	}
+	return
+}
+
+// Languages returns the language codes of all the audio tracks in this `AudioStreams`, the default one first.
+func (streams AudioStreams) Languages() (codes []string) {
+	seen := make(map[string]bool)
+	for _, s := range append(streams.Default(), streams...) {
+		if s.Language != "" && !seen[s.Language] {
+			seen[s.Language] = true
+			codes = append(codes, s.Language)
+		}
+	}
+	return
+}
+
+func matchLanguage(lang, code string) bool {
+	lang, code = strings.ToLower(lang), strings.ToLower(code)
+	return lang == code || strings.HasPrefix(lang, code+"-")
+}
+
 // First returns the first `AudioStream` object in this `AudioStreams`.
 func (streams AudioStreams) First() *AudioStream {
 	if len(streams) == 0 {
@@ -123,18 +230,15 @@ func (streams AudioStreams) Last() *AudioStream {
 	return streams[len(streams)-1]
 }
 
-// Best returns the `AudioStream` object with the highest overall bitrate.
-func (streams AudioStreams) Best() *AudioStream { return streams.sortByBitrate().Last() }
+// Sorted returns a copy of this `AudioStreams` sorted from the lowest to the highest rank,
+// that is by bitrate, then codec and container preference (see `SetPreference()`).
+func (streams AudioStreams) Sorted() AudioStreams { return preference.SortAudios(streams) }
 
-// Worst returns the `AudioStream` object with the lowest overall bitrate.
-func (streams AudioStreams) Worst() *AudioStream { return streams.sortByBitrate().First() }
+// Best returns the `AudioStream` object with the highest rank (see `Sorted()`).
+func (streams AudioStreams) Best() *AudioStream { return streams.Sorted().Last() }
 
-func (streams AudioStreams) sortByBitrate() AudioStreams {
-	sort.Slice(streams, func(i, j int) bool {
-		return streams[i].AverageBitrate < streams[j].AverageBitrate && streams[i].Bitrate < streams[j].Bitrate
-	})
-	return streams
-}
+// Worst returns the `AudioStream` object with the lowest rank (see `Sorted()`).
+func (streams AudioStreams) Worst() *AudioStream { return streams.Sorted().First() }
 
 // Streams represents a generic sequence of streams which can be of type 'video' or type 'audio'.
 type Streams []Stream
@@ -201,9 +305,11 @@ func (streams Streams) Filter(f func(int, Stream) bool) (results Streams) {
 }
 
 // Sort returns a sorted copy of this `Streams` according to the conditions of `less` (works the same with the sort package).
-func (streams Streams) Sort(less func(Stream, Stream) bool) (results Streams) {
-	sort.Slice(streams, func(i, j int) bool {
-		return less(streams[i], streams[j])
+// The sort is stable, and this `Streams` is left untouched.
+func (streams Streams) Sort(less func(Stream, Stream) bool) Streams {
+	results := append(Streams(nil), streams...)
+	sort.SliceStable(results, func(i, j int) bool {
+		return less(results[i], results[j])
 	})
 	return results
 }
@@ -228,17 +334,42 @@ type VideoStream struct {
 	cipher string
 	rawurl string
 
-	FileSize       int64     `json:"file_size"`
-	MimeType       string    `json:"mime_type"`
-	VideoCodec     string    `json:"video_codec"`
-	AudioCodec     string    `json:"audio_codec"`
-	Bitrate        int64     `json:"bitrate"`
-	AverageBitrate int64     `json:"average_bitrate"`
-	QualityLabel   string    `json:"quality_label"`
-	Width          int       `json:"width"`
-	Height         int       `json:"height"`
-	HasAudio       bool      `json:"has_audio"`
-	Expiration     time.Time `json:"expiration"`
+	FileSize       int64         `json:"file_size"`
+	MimeType       string        `json:"mime_type"`
+	VideoCodec     string        `json:"video_codec"`
+	AudioCodec     string        `json:"audio_codec"`
+	Bitrate        int64         `json:"bitrate"`
+	AverageBitrate int64         `json:"average_bitrate"`
+	QualityLabel   string        `json:"quality_label"`
+	Width          int           `json:"width"`
+	Height         int           `json:"height"`
+	FPS            int           `json:"fps"`
+	DynamicRange   string        `json:"dynamic_range"`
+	ColorPrimaries string        `json:"color_primaries"`
+	ProjectionType string        `json:"projection_type"`
+	HasAudio       bool          `json:"has_audio"`
+	Duration       time.Duration `json:"duration"`
+	Expiration     time.Time     `json:"expiration"`
+}
+
+type videoStreamJSON VideoStream
+
+// MarshalJSON marshals this stream with its duration in seconds.
+func (stream VideoStream) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		*videoStreamJSON
+		Duration float64 `json:"duration"`
+	}{(*videoStreamJSON)(&stream), seconds(stream.Duration)})
+}
+
+// IsHDR reports whether this stream is in high dynamic range.
+func (stream *VideoStream) IsHDR() bool {
+	return stream.DynamicRange != "" && stream.DynamicRange != "SDR"
+}
+
+// Is360 reports whether this stream is a 360° or VR video (i.e. not rectangularly projected).
+func (stream *VideoStream) Is360() bool {
+	return stream.ProjectionType != "" && stream.ProjectionType != "RECTANGULAR"
 }
 
 // ParentVideo returns a pointer to the video object that this stream belongs to.
@@ -291,7 +422,8 @@ func (stream *VideoStream) Download(destdir, filename string, overwrite bool, on
 	if filename == "" {
 		filename = stream.Name()
 	}
-	return download.Download(dlurl, destdir, filename, stream.Subtype(), overwrite, onStart, onProgress)
+	path, err = download.Download(dlurl, destdir, filename, stream.Subtype(), overwrite, onStart, onProgress)
+	return path, wrapStreamError(stream, "download", dlurl, err)
 }
 
 // AudioStream represents a stream of type 'audio'.
@@ -310,9 +442,26 @@ type AudioStream struct {
 	AverageBitrate int64             `json:"average_bitrate"`
 	SampleRate     int64             `json:"sample_rate"`
 	Channels       int               `json:"channels"`
+	AudioTrackID   string            `json:"audio_track_id"`
+	AudioTrackName string            `json:"audio_track_name"`
+	Language       string            `json:"language"`
+	IsDefaultTrack bool              `json:"is_default_track"`
+	IsDRC          bool              `json:"is_drc"`
+	LoudnessDB     float64           `json:"loudness_db"`
+	Duration       time.Duration     `json:"duration"`
 	Expiration     time.Time         `json:"expiration"`
 }
 
+type audioStreamJSON AudioStream
+
+// MarshalJSON marshals this stream with its duration in seconds.
+func (stream AudioStream) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		*audioStreamJSON
+		Duration float64 `json:"duration"`
+	}{(*audioStreamJSON)(&stream), seconds(stream.Duration)})
+}
+
 // ParentVideo returns a pointer to the video object that this stream belongs to.
 func (stream *AudioStream) ParentVideo() *Video { return stream.video }
 
@@ -361,5 +510,6 @@ func (stream *AudioStream) Download(destdir, filename string, overwrite bool, on
 	if filename == "" {
 		filename = stream.Name()
 	}
-	return download.Download(dlurl, destdir, filename, stream.Subtype(), overwrite, onStart, onProgress)
+	path, err = download.Download(dlurl, destdir, filename, stream.Subtype(), overwrite, onStart, onProgress)
+	return path, wrapStreamError(stream, "download", dlurl, err)
 }
@@ -0,0 +1,105 @@
+package gotube
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/tnychn/gotube/errors"
+	"github.com/tnychn/gotube/utils"
+)
+
+// Thumbnails represents a sequence of thumbnails.
+type Thumbnails []*Thumbnail
+
+// Best returns the `Thumbnail` with the highest resolution, or nil if there is none.
+func (thumbnails Thumbnails) Best() (best *Thumbnail) {
+	for _, thumbnail := range thumbnails {
+		if best == nil || thumbnail.Width*thumbnail.Height >= best.Width*best.Height {
+			best = thumbnail
+		}
+	}
+	return
+}
+
+// Thumbnail represents a thumbnail of a YouTube video.
+type Thumbnail struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// thumbnailChain lists the standard thumbnails of a video, from the highest resolution to the lowest.
+// The ones above 'hqdefault' do not exist for every video.
+var thumbnailChain = []Thumbnail{
+	{URL: "maxresdefault.jpg", Width: 1280, Height: 720},
+	{URL: "sddefault.jpg", Width: 640, Height: 480},
+	{URL: "hqdefault.jpg", Width: 480, Height: 360},
+	{URL: "mqdefault.jpg", Width: 320, Height: 180},
+}
+
+// ProbeThumbnail returns the standard thumbnail of this video with the highest resolution that exists,
+// trying 'maxresdefault', 'sddefault', 'hqdefault' then 'mqdefault' with HEAD requests.
+func (video *Video) ProbeThumbnail() (*Thumbnail, error) {
+	for _, thumbnail := range thumbnailChain {
+		thumbnail.URL = fmt.Sprintf("https://i.ytimg.com/vi/%v/%v", video.ID, thumbnail.URL)
+		exists, err := utils.HttpExists(thumbnail.URL)
+		if err != nil {
+			return nil, errors.OpError{Op: "probe thumbnail", URL: thumbnail.URL, VideoID: video.ID, Err: err}
+		}
+		if exists {
+			return &thumbnail, nil
+		}
+	}
+	return nil, fmt.Errorf("video %v has no standard thumbnail", video.ID)
+}
+
+// Save downloads this thumbnail and saves it to a file in the local machine.
+// If `destdir` is empty, it defaults to the current directory.
+// If `filename` is empty, it defaults to the name of the thumbnail in its url (e.g. 'maxresdefault'),
+// with the characters not allowed in filenames replaced.
+// The extension of the file follows the url (e.g. '.jpg' or '.webp').
+func (thumbnail *Thumbnail) Save(destdir, filename string) (finalpath string, err error) {
+	if destdir == "" {
+		if destdir, err = os.Getwd(); err != nil {
+			return
+		}
+	}
+	if destdir, err = filepath.Abs(destdir); err != nil {
+		return
+	}
+	u, err := url.Parse(thumbnail.URL)
+	if err != nil {
+		return
+	}
+	ext := path.Ext(u.Path)
+	if ext == "" {
+		ext = ".jpg"
+	}
+	if filename == "" {
+		filename = utils.SanitizeFilename(strings.TrimSuffix(path.Base(u.Path), ext))
+	}
+
+	content, err := utils.HttpFetch(thumbnail.URL)
+	if err != nil {
+		return "", errors.OpError{Op: "fetch thumbnail", URL: thumbnail.URL, Err: err}
+	}
+
+	partpath := filepath.Join(destdir, filename+".part")
+	file, err := os.Create(partpath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	if _, err = file.Write(content); err != nil {
+		return
+	}
+
+	finalpath = filepath.Join(destdir, filename+ext)
+	err = os.Rename(partpath, finalpath)
+	return
+}
@@ -0,0 +1,101 @@
+package gotube
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/tnychn/gotube/errors"
+	"github.com/tnychn/gotube/utils"
+)
+
+// redirectTransport sends every request to the test server instead of its host.
+type redirectTransport struct{ server *url.URL }
+
+func (transport redirectTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request = request.Clone(request.Context())
+	request.URL.Scheme, request.URL.Host = transport.server.Scheme, transport.server.Host
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+// thumbnailServer serves the thumbnails with the given status codes (200 if missing),
+// and records the method and path of every request.
+func thumbnailServer(t *testing.T, statuses map[string]int) *[]string {
+	var lock sync.Mutex
+	requests := new([]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		*requests = append(*requests, r.Method+" "+r.URL.Path)
+		lock.Unlock()
+		if status, ok := statuses[filepath.Base(r.URL.Path)]; ok {
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte("jpeg"))
+	}))
+	u, _ := url.Parse(server.URL)
+	client, retry := utils.Client, utils.Retry
+	utils.Client, utils.Retry = &http.Client{Transport: redirectTransport{u}}, utils.NoRetryPolicy
+	t.Cleanup(func() {
+		utils.Client, utils.Retry = client, retry
+		server.Close()
+	})
+	return requests
+}
+
+func TestProbeThumbnail(t *testing.T) {
+	requests := thumbnailServer(t, map[string]int{"maxresdefault.jpg": http.StatusNotFound, "sddefault.jpg": http.StatusGone})
+	video := &Video{ID: "dQw4w9WgXcQ"}
+	thumbnail, err := video.ProbeThumbnail()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Thumbnail{URL: "https://i.ytimg.com/vi/dQw4w9WgXcQ/hqdefault.jpg", Width: 480, Height: 360}); *thumbnail != want {
+		t.Errorf("got %+v, want %+v", *thumbnail, want)
+	}
+	want := []string{"HEAD /vi/dQw4w9WgXcQ/maxresdefault.jpg", "HEAD /vi/dQw4w9WgXcQ/sddefault.jpg", "HEAD /vi/dQw4w9WgXcQ/hqdefault.jpg"}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Errorf("got requests %v, want %v", *requests, want)
+	}
+}
+
+func TestProbeThumbnailErrors(t *testing.T) {
+	thumbnailServer(t, map[string]int{"maxresdefault.jpg": http.StatusForbidden})
+	video := &Video{ID: "dQw4w9WgXcQ"}
+	if _, err := video.ProbeThumbnail(); !errors.Is(err, errors.ErrHttp) {
+		t.Errorf("got %v, want a HttpError", err)
+	}
+
+	statuses := make(map[string]int)
+	for _, thumbnail := range thumbnailChain {
+		statuses[thumbnail.URL] = http.StatusNotFound
+	}
+	thumbnailServer(t, statuses)
+	if thumbnail, err := video.ProbeThumbnail(); err == nil {
+		t.Errorf("got %+v, want an error", thumbnail)
+	}
+}
+
+func TestThumbnailSave(t *testing.T) {
+	requests := thumbnailServer(t, nil)
+	dir := t.TempDir()
+	thumbnail := &Thumbnail{URL: "https://i.ytimg.com/vi/dQw4w9WgXcQ/hq:720.webp"}
+	path, err := thumbnail.Save(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "hq_720.webp"); path != want {
+		t.Errorf("saved as %q, want %q", path, want)
+	}
+	if content, err := ioutil.ReadFile(path); err != nil || string(content) != "jpeg" {
+		t.Errorf("saved %q (%v), want %q", content, err, "jpeg")
+	}
+	if want := []string{"GET /vi/dQw4w9WgXcQ/hq:720.webp"}; !reflect.DeepEqual(*requests, want) {
+		t.Errorf("got requests %v, want %v", *requests, want)
+	}
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseCookies parses cookies in the Netscape format (i.e. 'cookies.txt', as exported by most browser extensions)
+// and returns a cookie jar containing them. Expired cookies are skipped.
+// The fields are separated by tabs, so the values are kept as is (including surrounding spaces, or empty).
+func ParseCookies(r io.Reader) (http.CookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r\n")
+		httpOnly := strings.HasPrefix(line, "#HttpOnly_")
+		if httpOnly {
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) == 6 {
+			// some exporters drop the tab before an empty value
+			fields = append(fields, "")
+		}
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("cookies: line %d: expected 7 tab-separated fields, got %d", n, len(fields))
+		}
+		domain, includeSubdomains, path, secure := fields[0], fields[1] == "TRUE", fields[2], fields[3] == "TRUE"
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cookies: line %d: invalid expiration '%v'", n, fields[4])
+		}
+		cookie := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     path,
+			Secure:   secure,
+			HttpOnly: httpOnly,
+		}
+		// an expiration of 0 denotes a session cookie
+		if expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+			if cookie.Expires.Before(time.Now()) {
+				continue
+			}
+		}
+		host := strings.TrimPrefix(domain, ".")
+		if includeSubdomains {
+			cookie.Domain = host
+		}
+		scheme := "http"
+		if secure {
+			scheme = "https"
+		}
+		jar.SetCookies(&url.URL{Scheme: scheme, Host: host, Path: path}, []*http.Cookie{cookie})
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return jar, nil
+}
+
+// LoadCookies reads the Netscape-format cookie file at `path` (see `ParseCookies`).
+func LoadCookies(path string) (http.CookieJar, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseCookies(file)
+}
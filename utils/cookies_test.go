@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const cookiesTxt = "# Netscape HTTP Cookie File\r\n" +
+	"# http://curl.haxx.se/rfc/cookie_spec.html\r\n" +
+	"# This is a generated file!  Do not edit.\r\n" +
+	"\r\n" +
+	".youtube.com\tTRUE\t/\tTRUE\t4102444800\tPREF\tf6=40000000&hl=en\r\n" +
+	"#HttpOnly_.youtube.com\tTRUE\t/\tTRUE\t4102444800\tLOGIN_INFO\tAFmmF2swRQIhAK\r\n" +
+	"#HttpOnly_.youtube.com\tTRUE\t/\tTRUE\t0\tYSC\t\r\n" +
+	".youtube.com\tTRUE\t/\tFALSE\t0\tSPACED\t  padded value \r\n" +
+	".youtube.com\tTRUE\t/\tTRUE\t4102444800\tEMPTY\r\n" +
+	".youtube.com\tTRUE\t/\tTRUE\t946684800\tEXPIRED\tgone\r\n" +
+	"accounts.google.com\tFALSE\t/\tTRUE\t4102444800\tSID\tabc\r\n"
+
+func TestLoadCookies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := ioutil.WriteFile(path, []byte(cookiesTxt), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jar, err := LoadCookies(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse("https://www.youtube.com/watch?v=dQw4w9WgXcQ")
+	got := make(map[string]string)
+	for _, cookie := range jar.Cookies(u) {
+		got[cookie.Name] = cookie.Value
+	}
+	want := map[string]string{
+		"PREF":       "f6=40000000&hl=en",
+		"LOGIN_INFO": "AFmmF2swRQIhAK",
+		"YSC":        "",
+		"SPACED":     "  padded value ",
+		"EMPTY":      "",
+	}
+	for name, value := range want {
+		if v, ok := got[name]; !ok {
+			t.Errorf("cookie %v is missing", name)
+		} else if v != value {
+			t.Errorf("cookie %v = %q, want %q", name, v, value)
+		}
+	}
+	for _, name := range []string{"EXPIRED", "SID"} {
+		if _, ok := got[name]; ok {
+			t.Errorf("cookie %v should not be sent to %v", name, u.Host)
+		}
+	}
+	google, _ := url.Parse("https://accounts.google.com/")
+	if cookies := jar.Cookies(google); len(cookies) != 1 || cookies[0].Name != "SID" {
+		t.Errorf("got %v for %v, want SID", cookies, google.Host)
+	}
+}
+
+func TestParseCookiesInvalid(t *testing.T) {
+	for _, content := range []string{
+		".youtube.com\tTRUE\t/\tTRUE\n",
+		".youtube.com\tTRUE\t/\tTRUE\tnever\tPREF\tvalue\n",
+	} {
+		if _, err := ParseCookies(strings.NewReader(content)); err == nil {
+			t.Errorf("ParseCookies(%q): expected an error", content)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ProxyRule routes the requests to the matching hosts through a specific proxy.
+type ProxyRule struct {
+	// Host is either an exact host name (e.g. 'www.youtube.com') or a suffix pattern (e.g. '*.googlevideo.com').
+	Host string
+	// Proxy is the proxy to use, or nil to connect directly.
+	Proxy *url.URL
+}
+
+func (rule ProxyRule) match(host string) bool {
+	if strings.HasPrefix(rule.Host, "*.") {
+		return strings.HasSuffix(host, rule.Host[1:]) || host == rule.Host[2:]
+	}
+	return host == rule.Host
+}
+
+// ProxyConfig describes how the HTTP requests are sent over the network.
+type ProxyConfig struct {
+	// Proxies are used in rotation: all requests go through the same proxy until `Rotate()` is called.
+	// The schemes 'http', 'https' and 'socks5' are supported.
+	Proxies []*url.URL
+	// Rules take precedence over `Proxies` for the requests to the hosts they match, the first matching rule wins.
+	Rules []ProxyRule
+	// LocalAddr is the local (source) IP address to bind the outgoing connections to.
+	LocalAddr string
+
+	current uint32
+	next    uint32
+}
+
+// Rotate switches to the next proxy of `Proxies` (the first one at the first call).
+// The urls of the streams are bound to the IP address which fetched the video,
+// so a proxy must be kept from the initialization of a video until its streams are downloaded.
+func (config *ProxyConfig) Rotate() {
+	atomic.StoreUint32(&config.current, atomic.AddUint32(&config.next, 1)-1)
+}
+
+// ParseProxy parses a proxy specification into `config`, which is either a proxy url to be added to the rotation
+// or a per-host rule in the form of 'host=url' (where url can be 'direct').
+func (config *ProxyConfig) ParseProxy(spec string) error {
+	if parts := strings.SplitN(spec, "=", 2); len(parts) == 2 && !strings.Contains(parts[0], "://") {
+		rule := ProxyRule{Host: parts[0]}
+		if parts[1] != "direct" {
+			proxy, err := parseProxyURL(parts[1])
+			if err != nil {
+				return err
+			}
+			rule.Proxy = proxy
+		}
+		config.Rules = append(config.Rules, rule)
+		return nil
+	}
+	proxy, err := parseProxyURL(spec)
+	if err != nil {
+		return err
+	}
+	config.Proxies = append(config.Proxies, proxy)
+	return nil
+}
+
+func parseProxyURL(rawurl string) (*url.URL, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme '%v'", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing host in proxy url '%v'", rawurl)
+	}
+	return u, nil
+}
+
+// Proxy returns the proxy to be used for `request`, it has the signature of `http.Transport.Proxy`.
+func (config *ProxyConfig) Proxy(request *http.Request) (*url.URL, error) {
+	host := request.URL.Hostname()
+	for _, rule := range config.Rules {
+		if rule.match(host) {
+			return rule.Proxy, nil
+		}
+	}
+	if len(config.Proxies) == 0 {
+		return http.ProxyFromEnvironment(request)
+	}
+	i := atomic.LoadUint32(&config.current)
+	return config.Proxies[int(i)%len(config.Proxies)], nil
+}
+
+// Transport returns a new `http.Transport` which sends the requests according to this config.
+func (config *ProxyConfig) Transport() (*http.Transport, error) {
+	dialer := &net.Dialer{Timeout: time.Second * 30, KeepAlive: time.Second * 30}
+	if config.LocalAddr != "" {
+		ip := net.ParseIP(config.LocalAddr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid local address '%v'", config.LocalAddr)
+		}
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = config.Proxy
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return transport, nil
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tnychn/gotube/errors"
+)
+
+// RetryPolicy describes how failed HTTP requests are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts (including the first one) of a request.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, which is multiplied by `Multiplier` after every retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts, including the delay requested by a 'Retry-After' header.
+	MaxBackoff time.Duration
+	Multiplier float64
+	// Jitter randomizes each delay by up to the given fraction of it (e.g. 0.2 means ±20%).
+	Jitter float64
+	// RetryableStatuses lists the status codes that are retried.
+	// If nil, 429 and 5xx status codes are retried (see `errors.HttpError.Retryable()`).
+	RetryableStatuses []int
+}
+
+// DefaultRetryPolicy is the retry policy used by default.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond * 500,
+	MaxBackoff:     time.Second * 10,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// NoRetryPolicy performs every request only once.
+var NoRetryPolicy = RetryPolicy{MaxAttempts: 1}
+
+// Retry is the retry policy applied to all HTTP requests.
+// Each request reads it once before its first attempt without synchronization,
+// so it must be set before any request is made and not while requests are in flight.
+var Retry = DefaultRetryPolicy
+
+func (policy RetryPolicy) retryableStatus(code int) bool {
+	if policy.RetryableStatuses == nil {
+		return errors.HttpError{StatusCode: code}.Retryable()
+	}
+	for _, c := range policy.RetryableStatuses {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (policy RetryPolicy) shouldRetry(attempt int, response *http.Response, err error) bool {
+	if attempt >= policy.MaxAttempts {
+		return false
+	}
+	if err != nil {
+		return errors.Retryable(err)
+	}
+	return policy.retryableStatus(response.StatusCode)
+}
+
+// Backoff returns the delay before the next attempt after `attempt` attempts have been made.
+// The 'Retry-After' header of `response` is honoured if present.
+func (policy RetryPolicy) Backoff(attempt int, response *http.Response) time.Duration {
+	if response != nil {
+		if after, ok := retryAfter(response.Header.Get("Retry-After")); ok {
+			return policy.cap(after)
+		}
+	}
+	multiplier := policy.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(policy.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if policy.Jitter > 0 {
+		delay += delay * policy.Jitter * (rand.Float64()*2 - 1)
+	}
+	return policy.cap(time.Duration(delay))
+}
+
+func (policy RetryPolicy) cap(delay time.Duration) time.Duration {
+	if delay < 0 {
+		return 0
+	}
+	if policy.MaxBackoff > 0 && delay > policy.MaxBackoff {
+		return policy.MaxBackoff
+	}
+	return delay
+}
+
+// retryAfter parses the value of a 'Retry-After' header, which is either in seconds or a HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), true
+	}
+	return 0, false
+}
@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tnychn/gotube/errors"
+)
+
+// flakyServer fails the first `failures` requests with `status` (and `retryAfter` if not empty), then succeeds.
+func flakyServer(failures int32, status int, retryAfter string) (*httptest.Server, *int32) {
+	hits := new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(hits, 1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	return server, hits
+}
+
+func withRetry(t *testing.T, policy RetryPolicy) {
+	previous := Retry
+	Retry = policy
+	t.Cleanup(func() { Retry = previous })
+}
+
+func TestRetryFlakyServer(t *testing.T) {
+	withRetry(t, RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Millisecond, Multiplier: 2})
+	server, hits := flakyServer(3, http.StatusServiceUnavailable, "")
+	defer server.Close()
+	content, err := HttpFetch(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "ok" || atomic.LoadInt32(hits) != 4 {
+		t.Errorf("got %q after %d requests, want %q after 4", content, *hits, "ok")
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	withRetry(t, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+	server, hits := flakyServer(5, http.StatusBadGateway, "")
+	defer server.Close()
+	_, err := HttpFetch(server.URL)
+	var opErr errors.OpError
+	if !errors.As(err, &opErr) || opErr.Attempt != 3 {
+		t.Fatalf("got %#v, want an OpError after 3 attempts", err)
+	}
+	var httpErr errors.HttpError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("got %#v, want a 502 HttpError", err)
+	}
+	if atomic.LoadInt32(hits) != 3 {
+		t.Errorf("got %d requests, want 3", *hits)
+	}
+}
+
+func TestRetryNotRetryable(t *testing.T) {
+	withRetry(t, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+	server, hits := flakyServer(1, http.StatusForbidden, "")
+	defer server.Close()
+	if _, err := HttpFetch(server.URL); !errors.Is(err, errors.ErrHttp) {
+		t.Errorf("got %#v, want a HttpError", err)
+	}
+	if atomic.LoadInt32(hits) != 1 {
+		t.Errorf("got %d requests, want 1", *hits)
+	}
+}
+
+func TestRetryTruncatedBody(t *testing.T) {
+	withRetry(t, RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})
+	hits := new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "8")
+		if atomic.AddInt32(hits, 1) == 1 {
+			// the connection is closed after the first half of the declared length
+			_, _ = w.Write([]byte("trun"))
+			return
+		}
+		_, _ = w.Write([]byte("complete"))
+	}))
+	defer server.Close()
+	content, err := HttpFetch(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "complete" || atomic.LoadInt32(hits) != 2 {
+		t.Errorf("got %q after %d requests, want %q after 2", content, *hits, "complete")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	// the delay requested by the server (2 minutes) is capped by MaxBackoff
+	withRetry(t, RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Hour, MaxBackoff: 50 * time.Millisecond})
+	server, hits := flakyServer(1, http.StatusTooManyRequests, "120")
+	defer server.Close()
+	start := time.Now()
+	if _, err := HttpFetch(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 5*time.Second {
+		t.Errorf("retried after %v, want about 50ms", elapsed)
+	}
+	if atomic.LoadInt32(hits) != 2 {
+		t.Errorf("got %d requests, want 2", *hits)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 500 * time.Millisecond, MaxBackoff: 3 * time.Second, Multiplier: 2}
+	for attempt, want := range []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		if got := policy.Backoff(attempt+1, nil); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempt+1, got, want)
+		}
+	}
+	header := func(value string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": {value}}}
+	}
+	if got := policy.Backoff(1, header("2")); got != 2*time.Second {
+		t.Errorf("Backoff(Retry-After: 2) = %v, want 2s", got)
+	}
+	if got := policy.Backoff(1, header("60")); got != 3*time.Second {
+		t.Errorf("Backoff(Retry-After: 60) = %v, want 3s", got)
+	}
+	date := time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat)
+	if got := policy.Backoff(1, header(date)); got <= 0 || got > 2*time.Second {
+		t.Errorf("Backoff(Retry-After: %v) = %v, want at most 2s", date, got)
+	}
+	if got := policy.Backoff(1, header("soon")); got != 500*time.Millisecond {
+		t.Errorf("Backoff(Retry-After: soon) = %v, want 500ms", got)
+	}
+
+	policy.Jitter = 0.2
+	for i := 0; i < 100; i++ {
+		if got := policy.Backoff(2, nil); got < 800*time.Millisecond || got > 1200*time.Millisecond {
+			t.Fatalf("Backoff(2) with jitter = %v, want within 1s ±20%%", got)
+		}
+	}
+}
+
+func TestHttpHead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("got a %v request, want HEAD", r.Method)
+		}
+		w.Header().Set("Content-Length", "1234")
+	}))
+	defer server.Close()
+	header, err := HttpHead(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get("Content-Length"); got != "1234" {
+		t.Errorf("Content-Length = %q, want 1234", got)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/tnychn/gotube/errors"
@@ -12,46 +13,130 @@ import (
 
 const UserAgent string = "Mozilla/5.0"
 
+// Client is the HTTP client used by all requests.
+var Client = &http.Client{Timeout: time.Second * 120}
+
+// HttpRequest performs a HTTP request, retrying it according to the current `Retry` policy.
+// A response with an unsuccessful status code is returned as is after the last attempt.
 func HttpRequest(method, u string, headers *http.Header) (*http.Response, error) {
-	request, err := http.NewRequest(method, u, nil)
+	response, _, err := request(method, u, func() *http.Header { return headers }, nil)
+	return response, err
+}
+
+// HttpRead performs a GET request and passes its successful response to `read`, retrying both according to
+// the current `Retry` policy, so that a body cut short is requested again. `headers` (if not nil) is called before
+// every attempt, which allows a retried request to resume where the previous attempt stopped (e.g. with a 'Range' header).
+// An unsuccessful status code is returned as a `HttpError` after the last attempt.
+func HttpRead(u string, headers func() *http.Header, read func(response *http.Response) error) error {
+	response, attempts, err := request(http.MethodGet, u, headers, read)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	if headers != nil {
-		request.Header = *headers
+	return statusError(response, u, attempts)
+}
+
+// request performs a HTTP request, retrying it according to the current `Retry` policy.
+// If `read` is not nil, it is called with every successful response within the attempt, and its error is retried
+// the same way as that of the request. The body of the returned response is then already closed.
+func request(method, u string, headers func() *http.Header, read func(*http.Response) error) (*http.Response, int, error) {
+	policy := Retry
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequest(method, u, nil)
+		if err != nil {
+			panic(err)
+		}
+		if headers != nil {
+			if h := headers(); h != nil {
+				req.Header = h.Clone()
+			}
+		}
+		req.Header.Set("User-Agent", UserAgent)
+		response, err := Client.Do(req)
+		if err == nil && read != nil && response.StatusCode < 300 {
+			err = read(response)
+			response.Body.Close()
+			if err != nil {
+				response = nil
+			}
+		}
+		if !policy.shouldRetry(attempt, response, err) {
+			if err != nil && attempt > 1 {
+				err = errors.OpError{Op: "http " + strings.ToLower(method), URL: u, Attempt: attempt, Err: err}
+			}
+			return response, attempt, err
+		}
+		delay := policy.Backoff(attempt, response)
+		if response != nil {
+			response.Body.Close()
+		}
+		time.Sleep(delay)
 	}
-	request.Header.Set("User-Agent", UserAgent)
-	client := http.Client{Timeout: time.Second * 120}
-	return client.Do(request)
+}
+
+// statusError returns a `HttpError` if the status code of `response` is unsuccessful.
+func statusError(response *http.Response, u string, attempts int) error {
+	if response.StatusCode < 300 {
+		return nil
+	}
+	response.Body.Close()
+	var err error = errors.HttpError{StatusCode: response.StatusCode, URL: u}
+	if attempts > 1 {
+		err = errors.OpError{Op: "http " + strings.ToLower(response.Request.Method), URL: u, Attempt: attempts, Err: err}
+	}
+	return err
 }
 
 func HttpHead(u string) (header http.Header, err error) {
-	response, err := HttpRequest(http.MethodGet, u, nil)
+	response, attempts, err := request(http.MethodHead, u, nil, nil)
 	if err != nil {
 		return
 	}
-	if response.StatusCode >= 300 {
-		return nil, errors.HttpError{StatusCode: response.StatusCode}
+	if err = statusError(response, u, attempts); err != nil {
+		return
 	}
+	response.Body.Close()
 	return response.Header, nil
 }
 
-func HttpFetch(u string) ([]byte, error) {
-	response, err := HttpRequest(http.MethodGet, u, nil)
+// HttpExists performs a HEAD request and reports whether the resource at `u` exists.
+// A 404 or 410 status code is reported as false instead of an error.
+func HttpExists(u string) (bool, error) {
+	response, attempts, err := request(http.MethodHead, u, nil, nil)
 	if err != nil {
-		return nil, err
+		return false, err
+	}
+	if response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusGone {
+		response.Body.Close()
+		return false, nil
 	}
-	if response.StatusCode >= 300 {
-		return nil, errors.HttpError{StatusCode: response.StatusCode}
+	if err = statusError(response, u, attempts); err != nil {
+		return false, err
 	}
-	defer response.Body.Close()
-	content, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	return true, nil
+}
+
+// HttpFetch performs a GET request and returns the body of its response.
+// The body is read within each attempt, so a body cut short is retried as well.
+func HttpFetch(u string) ([]byte, error) {
+	var content []byte
+	err := HttpRead(u, nil, func(response *http.Response) (err error) {
+		content, err = ioutil.ReadAll(response.Body)
+		return
+	})
 	if err != nil {
 		return nil, err
 	}
 	return content, nil
 }
 
+var filenameReplacer = strings.NewReplacer("/", "_", `\`, "_", ":", "_", "*", "_", "?", "_", `"`, "_", "<", "_", ">", "_", "|", "_")
+
+// SanitizeFilename replaces the characters which are not allowed in filenames.
+func SanitizeFilename(name string) string {
+	return filenameReplacer.Replace(name)
+}
+
 func MakeInfoURL(videoID, el string) string {
 	eurl := url.PathEscape("https://youtube.googleapis.com/v/" + videoID)
 	u, _ := url.ParseRequestURI("https://www.youtube.com/get_video_info")
@@ -3,6 +3,7 @@ package gotube
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/url"
 	"regexp"
 	"strconv"
@@ -18,17 +19,88 @@ import (
 
 // VideoInfo carries information of a video.
 type VideoInfo struct {
-	Title           string
-	Description     string
-	Keywords        []string
-	Category        string
-	Duration        int
-	Views           int
-	AverageRating   float64
-	Author          string
-	ThumbnailURL    string
-	IsAgeRestricted bool
-	IsUnlisted      bool
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Keywords    []string `json:"keywords"`
+	Category    string   `json:"category"`
+	// Duration is marshaled into JSON as a whole number of seconds.
+	Duration time.Duration `json:"duration"`
+	Views    int           `json:"views"`
+	// LikeCount is scraped from the watch page, it is 0 if unavailable (e.g. hidden by the uploader).
+	LikeCount        int        `json:"like_count"`
+	AverageRating    float64    `json:"average_rating"`
+	AllowRatings     bool       `json:"allow_ratings"`
+	Author           string     `json:"author"`
+	ChannelID        string     `json:"channel_id"`
+	OwnerChannelName string     `json:"owner_channel_name"`
+	PublishDate      time.Time  `json:"publish_date"`
+	UploadDate       time.Time  `json:"upload_date"`
+	ThumbnailURL     string     `json:"thumbnail_url"`
+	Thumbnails       Thumbnails `json:"thumbnails"`
+	Embed            EmbedInfo  `json:"embed"`
+	// AvailableCountries lists the ISO 3166 codes of the countries where the video is available,
+	// as scraped from the watch page, or given by the microformat of the player response if the watch page
+	// does not list them. It is empty if neither lists them.
+	AvailableCountries []string `json:"available_countries,omitempty"`
+	IsAgeRestricted    bool     `json:"is_age_restricted"`
+	IsUnlisted         bool     `json:"is_unlisted"`
+	IsPrivate          bool     `json:"is_private"`
+}
+
+// EmbedInfo carries information of the embedded player of a video.
+type EmbedInfo struct {
+	IframeURL string `json:"iframe_url"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+}
+
+// IsAvailableIn reports whether the video is available in the country of the given ISO 3166 code (e.g. 'US'),
+// according to `AvailableCountries`.
+// It reports true if the countries are unknown.
+func (info *VideoInfo) IsAvailableIn(country string) bool {
+	if len(info.AvailableCountries) == 0 {
+		return true
+	}
+	for _, c := range info.AvailableCountries {
+		if strings.EqualFold(c, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// videoInfoJSON has the fields of VideoInfo without its JSON methods.
+type videoInfoJSON VideoInfo
+
+// MarshalJSON marshals this info with its duration in seconds, as it was before `Duration` became a `time.Duration`.
+func (info VideoInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		*videoInfoJSON
+		Duration int64 `json:"duration"`
+	}{(*videoInfoJSON)(&info), int64(info.Duration / time.Second)})
+}
+
+// UnmarshalJSON unmarshals this info with its duration in seconds (see `MarshalJSON()`).
+func (info *VideoInfo) UnmarshalJSON(b []byte) error {
+	v := struct {
+		*videoInfoJSON
+		Duration int64 `json:"duration"`
+	}{videoInfoJSON: (*videoInfoJSON)(info)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	info.Duration = time.Duration(v.Duration) * time.Second
+	return nil
+}
+
+// seconds returns `d` in seconds (to the millisecond), which is the unit of every other duration marshaled into JSON.
+func seconds(d time.Duration) float64 {
+	return float64(d.Milliseconds()) / 1000
+}
+
+// fromSeconds is the inverse of `seconds()`.
+func fromSeconds(s float64) time.Duration {
+	return time.Duration(math.Round(s*1000)) * time.Millisecond
 }
 
 // Video represents a YouTube video object, carrying the information, streams and captions of the video.
@@ -42,6 +114,7 @@ type Video struct {
 
 	streams  Streams
 	captions Captions
+	chapters Chapters
 
 	watchHTML string
 	embedHTML string
@@ -55,6 +128,10 @@ type Video struct {
 
 	decryption     *decrypt.Decryption
 	playerResponse *data.PlayerResponse
+	initialData    *data.InitialData
+	regionsAllowed []string
+	fetchedAt      time.Time
+	cached         bool
 }
 
 // NewVideo returns a new video object of the given `idurl` (can be one of video id or video url).
@@ -79,26 +156,63 @@ func NewVideo(idurl string, preinit bool) (vid *Video, err error) {
 }
 
 // Initialize performs all necessary HTTP requests and descrambles the fetched data.
+// Errors are wrapped in an `errors.OpError` carrying the ID of this video.
+// If a cache is set (see `SetCache()`) and it holds a fresh player response of this video, prefetching is skipped.
+// If multiple proxies are set (see `SetProxy()`), it switches to the next one.
 func (video *Video) Initialize() (err error) {
-	if err = video.prefetch(); err != nil {
-		return
+	rotateProxy()
+	video.cached = video.loadCache()
+	if !video.cached {
+		if err = video.prefetch(); err != nil {
+			return video.wrapError("prefetch", err)
+		}
 	}
 	if err = video.descramble(); err != nil {
-		return
+		if !video.cached {
+			return video.wrapError("descramble", err)
+		}
+		// the cached player response may be corrupted or outdated: fetch it again
+		video.dropCache()
+		video.jsURL = ""
+		video.playerResponse = nil
+		if err = video.prefetch(); err != nil {
+			return video.wrapError("prefetch", err)
+		}
+		if err = video.descramble(); err != nil {
+			return video.wrapError("descramble", err)
+		}
 	}
 	if err = video.obtainBasicInfo(); err != nil {
-		return
+		return video.wrapError("obtain info", err)
+	}
+	if !video.cached {
+		video.storeCache()
 	}
 	return nil
 }
 
+func (video *Video) wrapError(op string, err error) error {
+	var u string
+	var httpErr errors.HttpError
+	if errors.As(err, &httpErr) {
+		u = httpErr.URL
+	}
+	return errors.OpError{Op: op, URL: u, VideoID: video.ID, Err: err}
+}
+
 func (video *Video) prefetch() (err error) {
+	video.fetchedAt = time.Now()
 	// Watch HTML
 	content, err := utils.HttpFetch(video.WatchURL)
 	if err != nil {
 		return
 	}
 	video.watchHTML = string(content)
+	// Initial Data (optional, e.g. chapter markers)
+	if initialData, err := extract.InitialData(video.watchHTML); err == nil {
+		_ = json.Unmarshal([]byte(initialData), &video.initialData)
+	}
+	video.regionsAllowed = extract.RegionsAllowed(video.watchHTML)
 
 	video.IsAgeRestricted = extract.AgeRestricted(video.watchHTML)
 	// Embed HTML (if video is age-restricted)
@@ -127,7 +241,7 @@ func (video *Video) prefetch() (err error) {
 	// check and fallback
 	err = fetchInfo("embedded")
 	if err != nil {
-		if _, is := err.(errors.HttpError); is {
+		if errors.Is(err, errors.ErrHttp) {
 			return fetchInfo("detailpage")
 		}
 		return err
@@ -151,7 +265,11 @@ func (video *Video) descramble() (err error) {
 	video.infoQuery = values
 	// - handle errors
 	if video.infoQuery.Get("status") != "ok" {
-		return errors.RequestFailedError{Reason: video.infoQuery.Get("reason")}
+		reason := video.infoQuery.Get("reason")
+		if err = playabilityError(video.ID, "", reason); err != nil {
+			return
+		}
+		return errors.RequestFailedError{Reason: reason}
 	}
 	// - parse player response
 	resp := video.infoQuery.Get("player_response")
@@ -161,32 +279,55 @@ func (video *Video) descramble() (err error) {
 	if err = json.Unmarshal([]byte(resp), &video.playerResponse); err != nil {
 		return
 	}
-	if video.playerResponse.PlayabilityStatus.Status != "OK" {
-		return errors.RequestFailedError{Reason: video.playerResponse.PlayabilityStatus.Status} // video.playerResponse.PlayabilityStatus.Reason
+	if status := video.playerResponse.PlayabilityStatus; status.Status != "OK" {
+		if err = playabilityError(video.ID, status.Status, status.Reason); err != nil {
+			return
+		}
+		return errors.RequestFailedError{Reason: status.Status}
 	}
 
 	// Descramble `PlayerConfig` in order to find the endpoint to 'base.js' for later use (i.e. stream decryption)
+	if video.jsURL != "" {
+		return nil
+	}
 	html := video.watchHTML
 	if video.IsAgeRestricted {
 		html = video.embedHTML
 	}
-	config, err := extract.PlayerConfig(html)
-	if err != nil {
+	if video.jsURL, err = extract.PlayerJSURL(html); err != nil {
 		return
 	}
-	playerConfig := struct {
-		Args struct {
-			EmbeddedPlayerResponse string `json:"embedded_player_response"`
-		} `json:"args"`
-		Assets struct {
-			CSS string `json:"css"`
-			JS  string `json:"js"`
-		} `json:"assets"`
-	}{}
-	if err = json.Unmarshal([]byte(config), &playerConfig); err != nil {
-		return
+	return nil
+}
+
+// playabilityError maps the playability `status` and the human-readable `reason` given by YouTube
+// to one of the typed errors, or returns nil if the reason is not recognized.
+func playabilityError(videoID, status, reason string) error {
+	r := strings.ToLower(reason)
+	has := func(substrs ...string) bool {
+		for _, substr := range substrs {
+			if strings.Contains(r, substr) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case has("private"):
+		return errors.VideoPrivateError{VideoID: videoID, Reason: reason}
+	case has("members", "join this channel"):
+		return errors.VideoMembersOnlyError{VideoID: videoID, Reason: reason}
+	case has("copyright"):
+		return errors.VideoCopyrightError{VideoID: videoID, Reason: reason}
+	case has("country", "region", "location"):
+		return errors.VideoRegionBlockedError{VideoID: videoID, Reason: reason}
+	case status == "AGE_CHECK_REQUIRED" || status == "AGE_VERIFICATION_REQUIRED" || has("confirm your age", "age-restricted", "inappropriate"):
+		return errors.VideoAgeRestrictedError{VideoID: videoID, Reason: reason}
+	case has("removed", "terminated", "no longer available", "does not exist", "deleted"):
+		return errors.VideoRemovedError{VideoID: videoID, Reason: reason}
+	case status == "LOGIN_REQUIRED" || has("sign in"):
+		return errors.LoginRequiredError{VideoID: videoID, Reason: reason}
 	}
-	video.jsURL = "https://youtube.com" + playerConfig.Assets.JS
 	return nil
 }
 
@@ -195,28 +336,99 @@ func (video *Video) obtainBasicInfo() (err error) {
 	if details.IsLiveContent {
 		return errors.VideoUnsupportedError{VideoID: video.ID}
 	}
+	microformat := video.playerResponse.Microformat.PlayerMicroformatRenderer
 	video.VideoInfo = new(VideoInfo)
 	video.Title = details.Title
 	video.Description = details.ShortDescription
 	video.Keywords = details.Keywords
-	video.Category = video.playerResponse.Microformat.PlayerMicroformatRenderer.Category
-	video.Duration, _ = strconv.Atoi(details.LengthSeconds)
+	video.Category = microformat.Category
+	seconds, _ := strconv.Atoi(details.LengthSeconds)
+	video.Duration = time.Duration(seconds) * time.Second
 	video.Views, _ = strconv.Atoi(details.ViewCount)
+	video.LikeCount = video.likeCount()
 	video.AverageRating = details.AverageRating
+	video.AllowRatings = details.AllowRatings
 	video.Author = details.Author
-	if len(details.Thumbnail.Thumbnails) > 0 {
-		video.ThumbnailURL = details.Thumbnail.Thumbnails[len(details.Thumbnail.Thumbnails)-1].URL
+	video.ChannelID = details.ChannelID
+	video.OwnerChannelName = microformat.OwnerChannelName
+	video.PublishDate = parseDate(microformat.PublishDate)
+	video.UploadDate = parseDate(microformat.UploadDate)
+	video.Embed = EmbedInfo{IframeURL: microformat.Embed.IframeURL, Width: microformat.Embed.Width, Height: microformat.Embed.Height}
+	video.AvailableCountries = video.regionsAllowed
+	if len(video.AvailableCountries) == 0 {
+		video.AvailableCountries = microformat.AvailableCountries
+	}
+	video.IsPrivate = details.IsPrivate
+	for _, thumbnail := range details.Thumbnail.Thumbnails {
+		video.Thumbnails = append(video.Thumbnails, &Thumbnail{URL: thumbnail.URL, Width: thumbnail.Width, Height: thumbnail.Height})
+	}
+	if best := video.Thumbnails.Best(); best != nil {
+		video.ThumbnailURL = best.URL
 	} else {
-		video.ThumbnailURL = fmt.Sprintf("https://img.youtube.com/vi/%v/maxresdefault.jpg", video.ID)
+		// 'hqdefault' exists for every video (see `ProbeThumbnail()` for the higher resolutions)
+		video.ThumbnailURL = fmt.Sprintf("https://i.ytimg.com/vi/%v/hqdefault.jpg", video.ID)
 	}
-	video.IsUnlisted = video.playerResponse.Microformat.PlayerMicroformatRenderer.IsUnlisted
+	video.IsUnlisted = microformat.IsUnlisted
+	video.VideoInfo.IsAgeRestricted = video.IsAgeRestricted
 	return nil
 }
 
+// parseDate parses a date of the microformat, which is either a date (e.g. '2006-01-02') or a full RFC 3339 time.
+func parseDate(s string) time.Time {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t
+	}
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+var nonDigitPattern = regexp.MustCompile(`\D`)
+
+// likeCount returns the number of likes shown on the watch page, or 0 if it is unavailable.
+func (video *Video) likeCount() int {
+	if video.initialData == nil {
+		return 0
+	}
+	digits := func(s string) int {
+		n, _ := strconv.Atoi(nonDigitPattern.ReplaceAllString(s, ""))
+		return n
+	}
+	for _, content := range video.initialData.Contents.TwoColumnWatchNextResults.Results.Results.Contents {
+		info := content.VideoPrimaryInfoRenderer
+		if info == nil {
+			continue
+		}
+		for _, button := range info.VideoActions.MenuRenderer.TopLevelButtons {
+			// newer watch pages group the like and dislike buttons into a segmented button
+			for _, toggle := range []data.ToggleButtonRenderer{
+				button.ToggleButtonRenderer,
+				button.SegmentedLikeDislikeButtonRenderer.LikeButton.ToggleButtonRenderer,
+			} {
+				if toggle.DefaultIcon.IconType == "LIKE" {
+					// the label carries the exact count (e.g. '1,234 likes'), while the text is abbreviated (e.g. '1.2K')
+					return digits(toggle.DefaultText.Accessibility.AccessibilityData.Label)
+				}
+			}
+		}
+		// the tooltip of the sentiment bar is '<likes> / <dislikes>'
+		if tooltip := info.SentimentBar.SentimentBarRenderer.Tooltip; tooltip != "" {
+			return digits(strings.SplitN(tooltip, "/", 2)[0])
+		}
+	}
+	return 0
+}
+
+// expiration returns the time when the stream urls of the player response expire.
+func (video *Video) expiration() time.Time {
+	secs, _ := strconv.ParseInt(video.playerResponse.StreamingData.ExpiresInSeconds, 10, 64)
+	return video.fetchedAt.Add(time.Second * time.Duration(secs))
+}
+
 func (video *Video) stream(format data.StreamFormat) Stream {
 	filesize, _ := strconv.ParseInt(format.ContentLength, 10, 64)
-	expirationSecs, _ := strconv.ParseInt(video.playerResponse.StreamingData.ExpiresInSeconds, 10, 64)
-	expiration := time.Now().Add(time.Second * time.Duration(expirationSecs))
+	expiration := video.expiration()
+	durationMs, _ := strconv.ParseInt(format.ApproxDurationMs, 10, 64)
+	duration := time.Duration(durationMs) * time.Millisecond
 	mime, codecs := extract.MimeCodecs(format.MimeType)
 	if strings.Split(mime, "/")[0] == "audio" {
 		sampleRate, _ := strconv.ParseInt(format.AudioSampleRate, 10, 64)
@@ -233,6 +445,13 @@ func (video *Video) stream(format data.StreamFormat) Stream {
 			AverageBitrate: int64(format.AverageBitrate),
 			SampleRate:     sampleRate,
 			Channels:       format.AudioChannels,
+			AudioTrackID:   format.AudioTrack.ID,
+			AudioTrackName: format.AudioTrack.DisplayName,
+			Language:       trackLanguage(format.AudioTrack.ID),
+			IsDefaultTrack: format.AudioTrack.ID == "" || format.AudioTrack.AudioIsDefault,
+			IsDRC:          format.IsDrc,
+			LoudnessDB:     format.LoudnessDb,
+			Duration:       duration,
 			Expiration:     expiration,
 		}
 	}
@@ -240,6 +459,10 @@ func (video *Video) stream(format data.StreamFormat) Stream {
 	if quality == "" {
 		quality = format.Quality
 	}
+	fps := format.Fps
+	if fps == 0 {
+		fps = qualityFPS(quality)
+	}
 	var acodec string
 	hasAudio := len(codecs)%2 == 0
 	if hasAudio {
@@ -259,11 +482,46 @@ func (video *Video) stream(format data.StreamFormat) Stream {
 		QualityLabel:   quality,
 		Width:          format.Width,
 		Height:         format.Height,
+		FPS:            fps,
+		DynamicRange:   dynamicRange(format.ColorInfo.TransferCharacteristics, quality),
+		ColorPrimaries: format.ColorInfo.Primaries,
+		ProjectionType: format.ProjectionType,
 		HasAudio:       hasAudio,
+		Duration:       duration,
 		Expiration:     expiration,
 	}
 }
 
+// trackLanguage returns the language code of an audio track id (e.g. 'en' of 'en.4', 'pt-BR' of 'pt-BR.3').
+func trackLanguage(id string) string {
+	return strings.SplitN(id, ".", 2)[0]
+}
+
+// qualityFPS returns the frame rate denoted by a quality label (e.g. 60 for '1080p60'), which defaults to 30.
+// It is only used when the format does not carry the frame rate.
+func qualityFPS(label string) int {
+	if i := strings.LastIndex(label, "p"); i >= 0 && i < len(label)-1 {
+		if fps, err := strconv.Atoi(strings.TrimRight(label[i+1:], " HDR")); err == nil {
+			return fps
+		}
+	}
+	return 30
+}
+
+// dynamicRange returns the dynamic range ('SDR', 'HDR10' or 'HLG') from the transfer characteristics of a format.
+func dynamicRange(transfer, label string) string {
+	switch transfer {
+	case "COLOR_TRANSFER_CHARACTERISTICS_SMPTEST2084":
+		return "HDR10"
+	case "COLOR_TRANSFER_CHARACTERISTICS_ARIB_STD_B67":
+		return "HLG"
+	}
+	if strings.HasSuffix(label, "HDR") {
+		return "HDR10"
+	}
+	return "SDR"
+}
+
 // Streams retrieves all the available streams that belong to this video.
 func (video *Video) Streams() Streams {
 	if video.playerResponse == nil {
@@ -276,17 +534,42 @@ func (video *Video) Streams() Streams {
 	for _, format := range formats {
 		video.streams = append(video.streams, video.stream(format))
 	}
-	video.streams.Sort(func(stream1 Stream, stream2 Stream) bool {
+	video.streams = video.streams.Sort(func(stream1 Stream, stream2 Stream) bool {
 		return stream1.Itag() < stream2.Itag()
 	})
 	return video.streams
 }
 
-func (video *Video) caption(track data.CaptionTrack) *Caption {
+func (video *Video) caption(index int, track data.CaptionTrack) *Caption {
+	var audioTrackIDs []string
+	for _, audioTrack := range video.playerResponse.Captions.PlayerCaptionsTracklistRenderer.AudioTracks {
+		if audioTrack.AudioTrackID == "" {
+			continue
+		}
+		for _, i := range audioTrack.CaptionTrackIndices {
+			if i == index {
+				audioTrackIDs = append(audioTrackIDs, audioTrack.AudioTrackID)
+			}
+		}
+	}
+	var translations []TranslationLanguage
+	for _, language := range video.playerResponse.Captions.PlayerCaptionsTracklistRenderer.TranslationLanguages {
+		translations = append(translations, TranslationLanguage{
+			LanguageCode: language.LanguageCode,
+			Name:         language.LanguageName.SimpleText,
+		})
+	}
 	return &Caption{
+		translations: translations,
+		watchURL:     video.WatchURL,
 		URL:          track.BaseURL,
 		Name:         track.Name.SimpleText,
 		LanguageCode: track.LanguageCode,
+		Kind:         track.Kind,
+		// the vss id of an auto-generated caption is prefixed with 'a.' (e.g. 'a.en')
+		IsAutoGenerated: track.Kind == "asr" || strings.HasPrefix(track.VssID, "a."),
+		AudioTrackIDs:   audioTrackIDs,
+		IsTranslatable:  track.IsTranslatable,
 	}
 }
 
@@ -298,8 +581,8 @@ func (video *Video) Captions() Captions {
 	if video.captions != nil {
 		return video.captions
 	}
-	for _, track := range video.playerResponse.Captions.PlayerCaptionsTracklistRenderer.CaptionTracks {
-		video.captions = append(video.captions, video.caption(track))
+	for i, track := range video.playerResponse.Captions.PlayerCaptionsTracklistRenderer.CaptionTracks {
+		video.captions = append(video.captions, video.caption(i, track))
 	}
 	return video.captions
 }
@@ -0,0 +1,190 @@
+package gotube
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tnychn/gotube/data"
+	"github.com/tnychn/gotube/errors"
+)
+
+func TestPlayabilityError(t *testing.T) {
+	tests := []struct {
+		status string
+		reason string
+		want   error
+	}{
+		{"ERROR", "This video is private.", errors.VideoPrivateError{}},
+		{"UNPLAYABLE", "Join this channel to get access to members-only content like this video, and other exclusive perks.", errors.VideoMembersOnlyError{}},
+		{"UNPLAYABLE", "This video contains content from UMG, who has blocked it on copyright grounds.", errors.VideoCopyrightError{}},
+		{"UNPLAYABLE", "The uploader has not made this video available in your country.", errors.VideoRegionBlockedError{}},
+		{"LOGIN_REQUIRED", "Sign in to confirm your age", errors.VideoAgeRestrictedError{}},
+		{"AGE_VERIFICATION_REQUIRED", "", errors.VideoAgeRestrictedError{}},
+		{"ERROR", "This video has been removed by the uploader", errors.VideoRemovedError{}},
+		{"ERROR", "This video is no longer available because the YouTube account associated with this video has been terminated.", errors.VideoRemovedError{}},
+		{"LOGIN_REQUIRED", "", errors.LoginRequiredError{}},
+		{"ERROR", "Sign in to view this video", errors.LoginRequiredError{}},
+		{"ERROR", "Something went wrong", nil},
+		{"", "", nil},
+	}
+	for _, test := range tests {
+		err := playabilityError("dQw4w9WgXcQ", test.status, test.reason)
+		if test.want == nil {
+			if err != nil {
+				t.Errorf("playabilityError(%q, %q) = %v, want nil", test.status, test.reason, err)
+			}
+			continue
+		}
+		if !goerrors.Is(err, test.want) {
+			t.Errorf("playabilityError(%q, %q) = %#v, want a %T", test.status, test.reason, err, test.want)
+		}
+		if !goerrors.Is(err, errors.VideoUnavailableError{}) || !goerrors.Is(err, errors.ErrUnavailable) {
+			t.Errorf("playabilityError(%q, %q) = %#v, want it to unwrap to a VideoUnavailableError", test.status, test.reason, err)
+		}
+	}
+}
+
+func TestDescrambleUnrecognizedReason(t *testing.T) {
+	video := &Video{ID: "dQw4w9WgXcQ", infoRaw: url.Values{
+		"status":          {"ok"},
+		"player_response": {`{"playabilityStatus":{"status":"UNPLAYABLE","reason":"Something went wrong"}}`},
+	}.Encode()}
+	err := video.descramble()
+	if !goerrors.Is(err, errors.ErrRequestFailed) {
+		t.Errorf("descramble() = %#v, want a RequestFailedError", err)
+	}
+}
+
+// testStreams returns the streams of the player response in 'testdata/player_response.json',
+// which has the formats of a typical video (avc1, vp9 and av01 up to 2160p60 HDR, mp4a and opus).
+func testStreams(t *testing.T) Streams {
+	content, err := ioutil.ReadFile("testdata/player_response.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	video := &Video{ID: "dQw4w9WgXcQ", fetchedAt: time.Now()}
+	if err = json.Unmarshal(content, &video.playerResponse); err != nil {
+		t.Fatal(err)
+	}
+	return video.Streams()
+}
+
+func itags(streams Streams) (itags []int) {
+	for _, stream := range streams {
+		itags = append(itags, stream.Itag())
+	}
+	return
+}
+
+func TestLikeCount(t *testing.T) {
+	tests := []struct {
+		contents string
+		want     int
+	}{
+		{`[{"videoPrimaryInfoRenderer":{"videoActions":{"menuRenderer":{"topLevelButtons":[{"toggleButtonRenderer":{"defaultIcon":{"iconType":"LIKE"},"defaultText":{"simpleText":"1.2K","accessibility":{"accessibilityData":{"label":"1,234 likes"}}}}}]}}}}]`, 1234},
+		{`[{"videoPrimaryInfoRenderer":{"videoActions":{"menuRenderer":{"topLevelButtons":[{"segmentedLikeDislikeButtonRenderer":{"likeButton":{"toggleButtonRenderer":{"defaultIcon":{"iconType":"LIKE"},"defaultText":{"simpleText":"15M","accessibility":{"accessibilityData":{"label":"15,036,458 likes"}}}}},"dislikeButton":{"toggleButtonRenderer":{"defaultIcon":{"iconType":"DISLIKE"}}}}}]}}}}]`, 15036458},
+		{`[{"videoPrimaryInfoRenderer":{"sentimentBar":{"sentimentBarRenderer":{"tooltip":"9,876 / 54"}}}}]`, 9876},
+		{`[{"videoPrimaryInfoRenderer":{}}]`, 0},
+	}
+	for _, test := range tests {
+		video := &Video{ID: "dQw4w9WgXcQ", initialData: new(data.InitialData)}
+		contents := &video.initialData.Contents.TwoColumnWatchNextResults.Results.Results.Contents
+		if err := json.Unmarshal([]byte(test.contents), contents); err != nil {
+			t.Fatal(err)
+		}
+		if got := video.likeCount(); got != test.want {
+			t.Errorf("likeCount() = %d, want %d for %s", got, test.want, test.contents)
+		}
+	}
+}
+
+func TestVideoInfoJSON(t *testing.T) {
+	info := VideoInfo{Title: "Never Gonna Give You Up", Duration: 212 * time.Second}
+	b, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["duration"] != 212.0 || m["title"] != info.Title {
+		t.Errorf("marshaled duration = %v and title = %v, want 212 and %q", m["duration"], m["title"], info.Title)
+	}
+	var restored VideoInfo
+	if err = json.Unmarshal(b, &restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Duration != info.Duration || restored.Title != info.Title {
+		t.Errorf("restored %v and %q, want %v and %q", restored.Duration, restored.Title, info.Duration, info.Title)
+	}
+}
+
+func TestDurationsJSON(t *testing.T) {
+	fields := func(v interface{}) map[string]interface{} {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m map[string]interface{}
+		if err = json.Unmarshal(b, &m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	streams := testStreams(t)
+	tests := []struct {
+		v     interface{}
+		field string
+		want  float64
+	}{
+		{streams.Itag(18).Metadata(), "duration", 212.091},
+		{streams.Itag(140).Metadata(), "duration", 212.091},
+		{&Chapter{Title: "Chorus", Start: 43*time.Second + 500*time.Millisecond, End: 90 * time.Second}, "start", 43.5},
+		{Chapter{Title: "Chorus", Start: 43 * time.Second, End: 90 * time.Second}, "end", 90},
+		{CaptionMatch{Start: 18520 * time.Millisecond, End: 21 * time.Second, Text: "never gonna"}, "start", 18.52},
+		{StoryboardLevel{Interval: 2 * time.Second}, "interval", 2},
+		{StoryboardFrame{Index: 3, Time: 6 * time.Second}, "time", 6},
+	}
+	for _, test := range tests {
+		if got := fields(test.v)[test.field]; got != test.want {
+			t.Errorf("%s of %T = %v, want %v seconds", test.field, test.v, got, test.want)
+		}
+	}
+
+	var chapter Chapter
+	if err := json.Unmarshal([]byte(`{"title":"Chorus","start":43.5,"end":90}`), &chapter); err != nil {
+		t.Fatal(err)
+	}
+	if chapter.Title != "Chorus" || chapter.Start != 43500*time.Millisecond || chapter.End != 90*time.Second {
+		t.Errorf("unmarshaled %+v", chapter)
+	}
+}
+
+func TestAvailableCountries(t *testing.T) {
+	content := `{"videoDetails":{"lengthSeconds":"212"},"microformat":{"playerMicroformatRenderer":{"availableCountries":["GB","US"]}}}`
+	tests := []struct {
+		regionsAllowed []string
+		want           []string
+	}{
+		{[]string{"CA", "US"}, []string{"CA", "US"}},
+		{nil, []string{"GB", "US"}},
+	}
+	for _, test := range tests {
+		video := &Video{ID: "dQw4w9WgXcQ", regionsAllowed: test.regionsAllowed}
+		if err := json.Unmarshal([]byte(content), &video.playerResponse); err != nil {
+			t.Fatal(err)
+		}
+		if err := video.obtainBasicInfo(); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(video.AvailableCountries, test.want) {
+			t.Errorf("got %v with the watch page listing %v, want %v", video.AvailableCountries, test.regionsAllowed, test.want)
+		}
+	}
+}